@@ -0,0 +1,164 @@
+package backfill
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/michelemendel/binance/client"
+	"github.com/michelemendel/binance/entity"
+)
+
+// Historical klines stored as CSV, one file per symbol and interval, e.g. data/BTCFDUSD_1h.csv.
+
+var header = []string{
+	"open_time", "open", "high", "low", "close", "volume", "close_time",
+	"quote_asset_volume", "number_of_trades", "taker_buy_base_asset_volume", "taker_buy_quote_asset_volume",
+}
+
+// Path returns the file the klines of a symbol and interval are stored in.
+func Path(dir, symbol, interval string) string {
+	return filepath.Join(dir, fmt.Sprintf("%s_%s.csv", symbol, interval))
+}
+
+// Klines appends the closed klines of a symbol to its file, from the kline after the last one stored,
+// or from start if the file is new. Klines still open are left for the next run.
+// It returns the number of klines added.
+func Klines(client *client.Client, dir, symbol, interval string, start time.Time) (int, error) {
+	path := Path(dir, symbol, interval)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return 0, fmt.Errorf("error creating %s: %w", dir, err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("error opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	lastOpenTime, err := lastOpenTime(f)
+	if err != nil {
+		return 0, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	if lastOpenTime > 0 {
+		start = time.UnixMilli(lastOpenTime + 1)
+		slog.Info("resuming backfill", "file", path, "from", start.UTC())
+	}
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	// Each page is written in one write, so an interrupted backfill leaves at most a partial last line,
+	// which is removed when it resumes
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	flush := func() error {
+		w.Flush()
+		err := w.Error()
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(buf.Bytes())
+		buf.Reset()
+		return err
+	}
+	if size == 0 {
+		err = w.Write(header)
+		if err == nil {
+			err = flush()
+		}
+		if err != nil {
+			return 0, fmt.Errorf("error writing %s: %w", path, err)
+		}
+	}
+
+	added := 0
+	now := uint64(time.Now().UnixMilli())
+	err = client.KlinePages(symbol, interval, start, time.Time{}, func(page []entity.Kline) error {
+		for _, k := range page {
+			if k.CloseTime >= now {
+				break
+			}
+			err := w.Write(record(k))
+			if err != nil {
+				return err
+			}
+			added++
+		}
+		return flush()
+	})
+	if err != nil {
+		return added, fmt.Errorf("error backfilling %s: %w", path, err)
+	}
+	return added, nil
+}
+
+func record(k entity.Kline) []string {
+	return []string{
+		strconv.FormatUint(k.OpenTime, 10), k.Open, k.High, k.Low, k.Close, k.Volume,
+		strconv.FormatUint(k.CloseTime, 10), k.QuoteAssetVolume, strconv.FormatInt(k.NumberOfTrades, 10),
+		k.TakerBuyBaseAssetVolume, k.TakerBuyQuoteAssetVolume,
+	}
+}
+
+// lastOpenTime returns the open time of the last kline in the file, or 0 if it has none.
+// A last line that is cut off, missing its newline or some of its fields, is removed from the file.
+// Only the end of the file is read.
+func lastOpenTime(f *os.File) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	const tail = 4096
+	offset := size - tail
+	if offset < 0 {
+		offset = 0
+	}
+	buf := make([]byte, size-offset)
+	_, err = f.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	last, i := lastLine(buf)
+	if len(last) > 0 && !complete(last) {
+		slog.Warn("removing partial last line", "file", f.Name(), "line", string(last))
+		err = f.Truncate(offset + int64(i))
+		if err != nil {
+			return 0, err
+		}
+		last, _ = lastLine(buf[:i])
+	}
+	if len(last) == 0 || bytes.HasPrefix(last, []byte(header[0])) {
+		return 0, nil
+	}
+	field, _, _ := bytes.Cut(last, []byte(","))
+	openTime, err := strconv.ParseInt(string(field), 10, 64)
+	if err != nil || !complete(last) {
+		return 0, fmt.Errorf("last line is not a kline: %q", last)
+	}
+	return openTime, nil
+}
+
+// lastLine returns the last line of buf, with its newline if it has one, and where it starts.
+func lastLine(buf []byte) ([]byte, int) {
+	i := bytes.LastIndexByte(bytes.TrimSuffix(buf, []byte("\n")), '\n') + 1
+	return buf[i:], i
+}
+
+// complete tells if a line ends with a newline and has all the fields.
+func complete(line []byte) bool {
+	if !bytes.HasSuffix(line, []byte("\n")) {
+		return false
+	}
+	fields, err := csv.NewReader(bytes.NewReader(line)).Read()
+	return err == nil && len(fields) == len(header)
+}
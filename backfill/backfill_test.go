@@ -0,0 +1,117 @@
+package backfill
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michelemendel/binance/client"
+)
+
+// newKlineServer serves hourly klines until now, the last one still open, and counts the requests.
+func newKlineServer(t *testing.T) (*client.Client, *int) {
+	hour := time.Hour.Milliseconds()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		from, _ := strconv.ParseInt(r.URL.Query().Get("startTime"), 10, 64)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		open := (from + hour - 1) / hour * hour
+		var klines [][]any
+		for ; open <= time.Now().UnixMilli() && len(klines) < limit; open += hour {
+			klines = append(klines, []any{open, "1", "2", "0.5", "1.5", "10", open + hour - 1, "15", 3, "5", "7.5", "0"})
+		}
+		json.NewEncoder(w).Encode(klines)
+	}))
+	t.Cleanup(server.Close)
+	return client.NewClient("test", nil, "", "", server.URL, ""), &requests
+}
+
+func TestKlinesResumes(t *testing.T) {
+	start := time.Now().Add(-2500 * time.Hour).Truncate(time.Hour)
+	cl, requests := newKlineServer(t)
+	dir := t.TempDir()
+
+	added, err := Klines(cl, dir, "BTCFDUSD", "1h", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 2500 || *requests != 3 {
+		t.Fatalf("got %d klines in %d requests, want 2500 in 3", added, *requests)
+	}
+
+	// Nothing new is closed yet
+	added, err = Klines(cl, dir, "BTCFDUSD", "1h", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 0 {
+		t.Fatalf("got %d klines on resume, want 0", added)
+	}
+
+	data, err := os.ReadFile(Path(dir, "BTCFDUSD", "1h"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2501 || lines[0] != strings.Join(header, ",") {
+		t.Fatalf("got %d lines, header %q", len(lines), lines[0])
+	}
+	if !strings.HasPrefix(lines[1], strconv.FormatInt(start.UnixMilli(), 10)+",") {
+		t.Errorf("first kline %q doesn't open at start", lines[1])
+	}
+}
+
+func TestKlinesResumesAfterPartialLine(t *testing.T) {
+	start := time.Now().Add(-10 * time.Hour).Truncate(time.Hour)
+	cl, _ := newKlineServer(t)
+
+	tests := []struct {
+		name string
+		cut  int // Bytes cut off the last line
+	}{
+		{"missing newline", 1},
+		{"missing fields", 20},
+		{"partial open time", 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := Path(dir, "BTCFDUSD", "1h")
+			_, err := Klines(cl, dir, "BTCFDUSD", "1h", start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.SplitAfter(string(want), "\n")
+			if last := lines[len(lines)-2]; len(last) <= tt.cut {
+				t.Fatalf("last line %q is too short to cut %d bytes off", last, tt.cut)
+			}
+
+			// As left by a backfill killed while writing
+			err = os.WriteFile(path, want[:len(want)-tt.cut], 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			added, err := Klines(cl, dir, "BTCFDUSD", "1h", start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if added != 1 || string(got) != string(want) {
+				t.Errorf("got %d klines added and file\n%s\nwant 1 and\n%s", added, got, want)
+			}
+		})
+	}
+}
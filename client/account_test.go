@@ -0,0 +1,117 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/fakebinance"
+)
+
+// Responses as in the Binance API documentation
+const (
+	accountBody = `{"makerCommission":15,"takerCommission":15,"buyerCommission":0,"sellerCommission":0,
+		"commissionRates":{"maker":"0.00150000","taker":"0.00150000","buyer":"0.00000000","seller":"0.00000000"},
+		"canTrade":true,"canWithdraw":true,"canDeposit":true,"brokered":false,"requireSelfTradePrevention":false,"preventSor":false,
+		"updateTime":123456789,"accountType":"SPOT",
+		"balances":[{"asset":"BTC","free":"4723846.89208129","locked":"0.00000000"},{"asset":"LTC","free":"4763368.68006011","locked":"0.50000000"}],
+		"permissions":["SPOT"],"uid":354937868}`
+	tradeFeeBody      = `[{"symbol":"ADABNB","makerCommission":"0.001","takerCommission":"0.001"},{"symbol":"BNBBTC","makerCommission":"0.001","takerCommission":"0.001"}]`
+	walletBalanceBody = `[{"activate":true,"balance":"0","walletName":"Spot"},{"activate":true,"balance":"0.00012","walletName":"Funding"}]`
+)
+
+func TestAccount(t *testing.T) {
+	server, cl := newFakeBinance(t)
+	server.Script(http.MethodGet, c.PATH_ACCOUNT, fakebinance.Response{Body: accountBody})
+
+	account, err := cl.Account()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.MakerCommission != 15 || account.CommissionRates.Taker != "0.00150000" || !account.CanTrade ||
+		account.UpdateTime != 123456789 || account.AccountType != "SPOT" || len(account.Balances) != 2 ||
+		len(account.Permissions) != 1 || account.Uid != 354937868 {
+		t.Errorf("account: got %+v", account)
+	}
+	if params := server.Requests(http.MethodGet, c.PATH_ACCOUNT)[0].Params; params.Get("omitZeroBalances") != "true" {
+		t.Errorf("params: got %v", params)
+	}
+
+	tests := []struct {
+		asset        string
+		free, locked string
+	}{
+		{"BTC", "4723846.89208129", "0.00000000"},
+		{"LTC", "4763368.68006011", "0.50000000"},
+		{"ETH", "0", "0"}, // Left out as a zero balance
+	}
+	for _, tt := range tests {
+		b := account.Balance(tt.asset)
+		if b.Asset != tt.asset || b.Free != tt.free || b.Locked != tt.locked {
+			t.Errorf("balance of %s: got %+v, want %s free and %s locked", tt.asset, b, tt.free, tt.locked)
+		}
+	}
+
+	server.Script(http.MethodGet, c.PATH_ACCOUNT, fakebinance.Response{Body: accountBody})
+	free, locked, err := cl.Balance("LTC")
+	if err != nil || free != 4763368.68006011 || locked != 0.5 {
+		t.Errorf("LTC balance: got %v free, %v locked, %v", free, locked, err)
+	}
+	server.SetBalance("FDUSD", 250)
+	free, locked, err = cl.Balance("FDUSD")
+	if err != nil || free != 250 || locked != 0 {
+		t.Errorf("FDUSD balance: got %v free, %v locked, %v", free, locked, err)
+	}
+	free, locked, err = cl.Balance("XRP")
+	if err != nil || free != 0 || locked != 0 {
+		t.Errorf("XRP balance: got %v free, %v locked, %v", free, locked, err)
+	}
+}
+
+func TestTradeFee(t *testing.T) {
+	server, cl := newFakeBinance(t)
+	server.Script(http.MethodGet, c.PATH_TRADE_FEE,
+		fakebinance.Response{Body: tradeFeeBody},
+		fakebinance.Response{Body: `[{"symbol":"ADABNB","makerCommission":"0.001","takerCommission":"0.001"}]`})
+
+	fees, err := cl.TradeFee("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fees) != 2 || fees[1].Symbol != "BNBBTC" || fees[1].MakerCommission != "0.001" || fees[1].TakerCommission != "0.001" {
+		t.Errorf("fees: got %+v", fees)
+	}
+	fees, err = cl.TradeFee("ADABNB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fees) != 1 || fees[0].Symbol != "ADABNB" {
+		t.Errorf("fees of ADABNB: got %+v", fees)
+	}
+
+	requests := server.Requests(http.MethodGet, c.PATH_TRADE_FEE)
+	if requests[0].Params.Has("symbol") || requests[1].Params.Get("symbol") != "ADABNB" {
+		t.Errorf("params: got %v and %v", requests[0].Params, requests[1].Params)
+	}
+	for _, r := range requests {
+		if r.APIKey == "" || !r.Params.Has("signature") {
+			t.Errorf("request not signed: %+v", r)
+		}
+	}
+}
+
+func TestWalletBalance(t *testing.T) {
+	server, cl := newFakeBinance(t)
+	server.Script(http.MethodGet, c.PATH_WALLET_BALANCE, fakebinance.Response{Body: walletBalanceBody})
+
+	balances, err := cl.WalletBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 2 || balances[1].WalletName != "Funding" || balances[1].Balance != "0.00012" || !balances[1].Activate {
+		t.Errorf("wallet balances: got %+v", balances)
+	}
+	if r := server.Requests(http.MethodGet, c.PATH_WALLET_BALANCE)[0]; r.APIKey == "" || !r.Params.Has("signature") {
+		t.Errorf("request not signed: %+v", r)
+	}
+}
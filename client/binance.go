@@ -3,13 +3,15 @@ package client
 import (
 	"context"
 	"fmt"
-	"os"
-	"regexp"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
-	binance_connector "github.com/binance/binance-connector-go"
 	c "github.com/michelemendel/binance/constant"
 	"github.com/michelemendel/binance/entity"
+	"github.com/michelemendel/binance/pnl"
 	"github.com/michelemendel/binance/util"
 )
 
@@ -18,119 +20,1003 @@ import (
 
 // https://binance-docs.github.io/apidocs/spot/en/#new-order-trade
 // symbol-BTCFDUSD, type-MARKET, quantity-0.001, orderType-Market
-func (c Client) Buy(pair string, quoteOrderQuantity, quantity float64) (*binance_connector.CreateOrderResponseFULL, error) {
-	order, err := c.Order("BUY", pair, quoteOrderQuantity, quantity)
+func (client Client) Buy(req entity.OrderRequest) (*entity.CreateOrderResp, error) {
+	req.Side = c.SIDE_BUY
+	order, err := client.Order(req)
 	if err != nil {
 		return nil, err
 	}
 	return order, nil
 }
 
-func (c Client) Sell(pair string, quoteOrderQuantity, quantity float64) (*binance_connector.CreateOrderResponseFULL, error) {
-	order, err := c.Order("SELL", pair, quoteOrderQuantity, quantity)
+func (client Client) Sell(req entity.OrderRequest) (*entity.CreateOrderResp, error) {
+	req.Side = c.SIDE_SELL
+	order, err := client.Order(req)
 	if err != nil {
 		return nil, err
 	}
 	return order, nil
 }
 
-func (c Client) Order(side, pair string, quoteOrderQuantity, quantity float64) (*binance_connector.CreateOrderResponseFULL, error) {
-	fmt.Printf("side:%s, pair:%s, quoteOrderQuantity:%v, quantity:%v\n", side, pair, quoteOrderQuantity, quantity)
+// Order validates and places an order.
+// In dry-run mode, set on the client or on the request, the order is sent to the test endpoint instead,
+// and the would-be order is returned together with the commission rates that would apply.
+func (client Client) Order(req entity.OrderRequest) (*entity.CreateOrderResp, error) {
+	fmt.Printf("side:%s, pair:%s, type:%s, quoteOrderQuantity:%v, quantity:%v, price:%v, stopPrice:%v, dryRun:%v\n",
+		req.Side, req.Symbol, req.Type, req.QuoteOrderQty, req.Quantity, req.Price, req.StopPrice, client.DryRun || req.DryRun)
 
-	orderType := "MARKET"
-	newOrder := c.Conn.
-		NewCreateOrderService().
-		Symbol(pair).
-		Side(side).
-		Type(orderType)
+	if req.Type == "" {
+		req.Type = c.ORDER_TYPE_MARKET
+	}
+	if req.NewClientOrderId == "" {
+		req.NewClientOrderId = util.NewClientOrderId()
+	}
+	err := checkOrderRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := client.SymbolInfo(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	req, err = ValidateOrder(*symbol, req)
+	if err != nil {
+		return nil, err
+	}
 
-	if quoteOrderQuantity > 0 {
-		newOrder = newOrder.QuoteOrderQty(quoteOrderQuantity)
-	} else if quantity > 0 {
-		newOrder = newOrder.Quantity(quantity)
-	} else {
-		return nil, fmt.Errorf("quoteOrderQuantity or quantity must be greater than 0")
+	params := orderRequestParams(req)
+	if client.DryRun || req.DryRun {
+		order, err := client.testOrder(req, params)
+		if err != nil {
+			return nil, err
+		}
+		client.record(*order, req.Strategy)
+		return order, nil
 	}
 
-	resp, err := newOrder.Do(context.Background())
+	params.Set("newOrderRespType", c.ORDER_RESP_TYPE_FULL)
+	order, err := client.placeOrder(req, params)
 	if err != nil {
-		return nil, fmt.Errorf("error creating order: %v", err)
+		return nil, fmt.Errorf("error creating order: %w", err)
 	}
 
-	order := resp.(*binance_connector.CreateOrderResponseFULL)
-	// fmt.Println(binance_connector.PrettyPrint(resp))
 	util.PP(order)
+	client.record(*order, req.Strategy)
 	return order, nil
 }
 
+// record adds the order and its fills to the journal, if the client has one.
+// The order has been placed at this point, so a failure is logged rather than returned.
+// Fills made after the order response are added by SyncFills and the user data stream.
+func (client Client) record(order entity.CreateOrderResp, strategy string) {
+	if client.Journal == nil {
+		return
+	}
+	err := client.Journal.Record(order, strategy, client.Env)
+	if err != nil {
+		slog.Error("error recording order in journal", "clientOrderId", order.ClientOrderId, "error", err)
+	}
+	if !order.DryRun {
+		client.recordFills(tradesFromOrder(order))
+	}
+}
+
+// recordFills adds fills to the journal and the PnL engine, if the client has them.
+// A failure is logged rather than returned.
+func (client Client) recordFills(trades []entity.Trade) {
+	if client.Journal != nil {
+		for _, t := range trades {
+			err := client.Journal.RecordFill(t, client.Env)
+			if err != nil {
+				slog.Error("error recording fill in journal", "symbol", t.Symbol, "tradeId", t.Id, "error", err)
+			}
+		}
+	}
+	if client.PnL != nil {
+		err := client.addFills(client.PnL, trades)
+		if err != nil {
+			slog.Error("error adding fills to PnL", "error", err)
+		}
+	}
+}
+
+// SyncFills adds the account's trades in a symbol made since the last sync to the journal, and the PnL engine
+// if the client has one, and returns them.
+// This catches the fills of orders that were resting when placed. The first sync adds all the trades in the symbol.
+func (client Client) SyncFills(symbol string) ([]entity.Trade, error) {
+	if client.Journal == nil {
+		return nil, fmt.Errorf("error syncing fills of %s: no journal", symbol)
+	}
+	synced, err := client.Journal.SyncedTradeId(symbol, client.Env)
+	if err != nil {
+		return nil, err
+	}
+	trades, err := client.myTradesFromId(symbol, synced+1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error syncing fills of %s: %w", symbol, err)
+	}
+	err = client.Journal.RecordSync(symbol, trades, client.Env)
+	if err != nil {
+		return nil, err
+	}
+	if client.PnL != nil {
+		err = client.addFills(client.PnL, trades)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return trades, nil
+}
+
+// tradesFromOrder converts the fills of an order response to trades, as listed by MyTrades.
+// Fills in the response are made when the order is placed, so the order is the taker.
+func tradesFromOrder(order entity.CreateOrderResp) []entity.Trade {
+	trades := make([]entity.Trade, len(order.Fills))
+	for i, f := range order.Fills {
+		trades[i] = entity.Trade{
+			Symbol:          order.Symbol,
+			Id:              f.TradeId,
+			OrderId:         order.OrderId,
+			OrderListId:     order.OrderListId,
+			Price:           f.Price,
+			Qty:             f.Qty,
+			QuoteQty:        util.Float2String(util.String2Float(f.Price) * util.String2Float(f.Qty)),
+			Commission:      f.Commission,
+			CommissionAsset: f.CommissionAsset,
+			Time:            order.TransactTime,
+			IsBuyer:         order.Side == c.SIDE_BUY,
+		}
+	}
+	return trades
+}
+
+// tradeFromExecutionReport converts an execution report of a fill, with execution type TRADE, to a trade.
+func tradeFromExecutionReport(e entity.ExecutionReport) entity.Trade {
+	return entity.Trade{
+		Symbol:          e.Symbol,
+		Id:              e.TradeId,
+		OrderId:         e.OrderId,
+		OrderListId:     e.OrderListId,
+		Price:           e.LastExecutedPrice,
+		Qty:             e.LastExecutedQty,
+		QuoteQty:        e.LastQuoteQty,
+		Commission:      e.Commission,
+		CommissionAsset: e.CommissionAsset,
+		Time:            uint64(e.TransactionTime),
+		IsBuyer:         e.Side == c.SIDE_BUY,
+		IsMaker:         e.IsMaker,
+	}
+}
+
+// placeOrder sends a new order, retrying if the request fails because of the network or the server.
+// Since such an order may have been placed anyway, it's looked up by its client order id before it's sent again.
+// The fills of an order found this way are taken from the trade list.
+func (client Client) placeOrder(req entity.OrderRequest, params url.Values) (*entity.CreateOrderResp, error) {
+	for attempt := 1; ; attempt++ {
+		var order entity.CreateOrderResp
+		err := client.call(http.MethodPost, c.PATH_ORDER, params, c.SECURITY_TYPE_TRADE, &order)
+		if err == nil {
+			return &order, nil
+		}
+		if !isRetryable(err) || attempt >= client.Retry.MaxAttempts {
+			return nil, err
+		}
+
+		delay := client.Retry.Backoff(attempt)
+		slog.Warn("order request failed, checking if it was placed", "clientOrderId", req.NewClientOrderId, "delay", delay, "error", err)
+		time.Sleep(delay)
+
+		existing, qerr := client.GetOrder(req.Symbol, 0, req.NewClientOrderId)
+		if qerr == nil {
+			slog.Info("order was placed", "clientOrderId", req.NewClientOrderId, "orderId", existing.OrderId)
+			var trades []entity.Trade
+			if util.String2Float(existing.ExecutedQty) > 0 {
+				trades, qerr = client.orderTrades(req.Symbol, existing.OrderId)
+				if qerr != nil {
+					// The order was placed, so it's returned anyway, and SyncFills adds the fills later
+					slog.Error("error getting fills of order", "clientOrderId", req.NewClientOrderId, "error", qerr)
+				}
+			}
+			return createOrderRespFromOrder(existing, trades), nil
+		}
+		if !IsAPIError(qerr, c.ERROR_CODE_NO_SUCH_ORDER) {
+			return nil, fmt.Errorf("order status unknown after %v: %w", err, qerr)
+		}
+	}
+}
+
+func createOrderRespFromOrder(o *entity.Order, trades []entity.Trade) *entity.CreateOrderResp {
+	fills := make([]entity.Fill, len(trades))
+	for i, t := range trades {
+		fills[i] = entity.Fill{Price: t.Price, Qty: t.Qty, Commission: t.Commission, CommissionAsset: t.CommissionAsset, TradeId: t.Id}
+	}
+	return &entity.CreateOrderResp{
+		Symbol:                  o.Symbol,
+		OrderId:                 o.OrderId,
+		OrderListId:             o.OrderListId,
+		ClientOrderId:           o.ClientOrderId,
+		TransactTime:            o.Time,
+		Price:                   o.Price,
+		OrigQty:                 o.OrigQty,
+		ExecutedQty:             o.ExecutedQty,
+		CummulativeQuoteQty:     o.CummulativeQuoteQty,
+		Status:                  o.Status,
+		TimeInForce:             o.TimeInForce,
+		Type:                    o.Type,
+		Side:                    o.Side,
+		StopPrice:               o.StopPrice,
+		IcebergQty:              o.IcebergQty,
+		WorkingTime:             o.WorkingTime,
+		SelfTradePreventionMode: o.SelfTradePreventionMode,
+		Fills:                   fills,
+	}
+}
+
+// Test New Order (TRADE)
+// https://binance-docs.github.io/apidocs/spot/en/#test-new-order-trade
+// The order is validated by Binance but never reaches the matching engine.
+func (client Client) testOrder(req entity.OrderRequest, params url.Values) (*entity.CreateOrderResp, error) {
+	params.Set("computeCommissionRates", "true")
+	var rates entity.TestOrderResp
+	err := client.call(http.MethodPost, c.PATH_ORDER_TEST, params, c.SECURITY_TYPE_TRADE, &rates)
+	if err != nil {
+		return nil, fmt.Errorf("error testing order: %w", err)
+	}
+
+	order := entity.CreateOrderResp{
+		Symbol:          req.Symbol,
+		OrderListId:     -1,
+		ClientOrderId:   req.NewClientOrderId,
+		TransactTime:    uint64(util.TimeNowInMillis()),
+		Price:           util.Float2String(req.Price),
+		OrigQty:         util.Float2String(req.Quantity),
+		TimeInForce:     req.TimeInForce,
+		Type:            req.Type,
+		Side:            req.Side,
+		StopPrice:       util.Float2String(req.StopPrice),
+		IcebergQty:      util.Float2String(req.IcebergQty),
+		Fills:           []entity.Fill{},
+		DryRun:          true,
+		CommissionRates: &rates,
+	}
+
+	util.PP(order)
+	return &order, nil
+}
+
+// checkOrderRequest verifies that the parameters required by the order type are present.
+// https://binance-docs.github.io/apidocs/spot/en/#new-order-trade (Additional mandatory parameters based on type)
+func checkOrderRequest(req entity.OrderRequest) error {
+	if req.Symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+	if req.Side != c.SIDE_BUY && req.Side != c.SIDE_SELL {
+		return fmt.Errorf("side must be %s or %s, got %q", c.SIDE_BUY, c.SIDE_SELL, req.Side)
+	}
+
+	needsTimeInForce := false
+	needsPrice := false
+	needsStopPrice := false
+	switch req.Type {
+	case c.ORDER_TYPE_MARKET:
+		if req.QuoteOrderQty <= 0 && req.Quantity <= 0 {
+			return fmt.Errorf("quoteOrderQuantity or quantity must be greater than 0")
+		}
+	case c.ORDER_TYPE_LIMIT:
+		needsTimeInForce, needsPrice = true, true
+	case c.ORDER_TYPE_LIMIT_MAKER:
+		needsPrice = true
+	case c.ORDER_TYPE_STOP_LOSS, c.ORDER_TYPE_TAKE_PROFIT:
+		needsStopPrice = true
+	case c.ORDER_TYPE_STOP_LOSS_LIMIT, c.ORDER_TYPE_TAKE_PROFIT_LIMIT:
+		needsTimeInForce, needsPrice, needsStopPrice = true, true, true
+	default:
+		return fmt.Errorf("unknown order type %q", req.Type)
+	}
+
+	if req.Type != c.ORDER_TYPE_MARKET {
+		if req.Quantity <= 0 {
+			return fmt.Errorf("quantity must be greater than 0 for %s orders", req.Type)
+		}
+		if req.QuoteOrderQty > 0 {
+			return fmt.Errorf("quoteOrderQuantity is only allowed for %s orders", c.ORDER_TYPE_MARKET)
+		}
+	}
+	if needsPrice && req.Price <= 0 {
+		return fmt.Errorf("price must be greater than 0 for %s orders", req.Type)
+	}
+	if needsStopPrice && req.StopPrice <= 0 {
+		return fmt.Errorf("stopPrice must be greater than 0 for %s orders", req.Type)
+	}
+	if needsTimeInForce {
+		switch req.TimeInForce {
+		case c.TIME_IN_FORCE_GTC, c.TIME_IN_FORCE_IOC, c.TIME_IN_FORCE_FOK:
+		default:
+			return fmt.Errorf("timeInForce must be GTC, IOC or FOK for %s orders, got %q", req.Type, req.TimeInForce)
+		}
+	} else if req.TimeInForce != "" {
+		return fmt.Errorf("timeInForce is not allowed for %s orders", req.Type)
+	}
+
+	// Iceberg orders must be GTC limit orders
+	if req.IcebergQty > 0 {
+		switch req.Type {
+		case c.ORDER_TYPE_LIMIT, c.ORDER_TYPE_STOP_LOSS_LIMIT, c.ORDER_TYPE_TAKE_PROFIT_LIMIT:
+			if req.TimeInForce != c.TIME_IN_FORCE_GTC {
+				return fmt.Errorf("icebergQty requires timeInForce %s", c.TIME_IN_FORCE_GTC)
+			}
+		case c.ORDER_TYPE_LIMIT_MAKER:
+		default:
+			return fmt.Errorf("icebergQty is not allowed for %s orders", req.Type)
+		}
+		if req.IcebergQty >= req.Quantity {
+			return fmt.Errorf("icebergQty must be less than quantity")
+		}
+	}
+	return nil
+}
+
+// Query Order (USER_DATA)
+// https://binance-docs.github.io/apidocs/spot/en/#query-order-user_data
+// Either orderId or origClientOrderId must be given.
+func (client Client) GetOrder(symbol string, orderId int64, origClientOrderId string) (*entity.Order, error) {
+	params, err := orderParams(symbol, orderId, origClientOrderId)
+	if err != nil {
+		return nil, err
+	}
+	var order entity.Order
+	err = client.call(http.MethodGet, c.PATH_ORDER, params, c.SECURITY_TYPE_USER_DATA, &order)
+	if err != nil {
+		return nil, fmt.Errorf("error getting order: %w", err)
+	}
+	return &order, nil
+}
+
+// Cancel Order (TRADE)
+// https://binance-docs.github.io/apidocs/spot/en/#cancel-order-trade
+// Either orderId or origClientOrderId must be given.
+func (client Client) CancelOrder(symbol string, orderId int64, origClientOrderId string) (*entity.CanceledOrder, error) {
+	params, err := orderParams(symbol, orderId, origClientOrderId)
+	if err != nil {
+		return nil, err
+	}
+	var order entity.CanceledOrder
+	err = client.call(http.MethodDelete, c.PATH_ORDER, params, c.SECURITY_TYPE_TRADE, &order)
+	if err != nil {
+		return nil, fmt.Errorf("error canceling order: %w", err)
+	}
+	return &order, nil
+}
+
+// Cancel all Open Orders on a Symbol (TRADE)
+// https://binance-docs.github.io/apidocs/spot/en/#cancel-all-open-orders-on-a-symbol-trade
+// Orders that are part of an order list (OCO) are returned with only the order list fields set.
+func (client Client) CancelAllOpenOrders(symbol string) ([]entity.CanceledOrder, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	var orders []entity.CanceledOrder
+	err := client.call(http.MethodDelete, c.PATH_OPEN_ORDERS, params, c.SECURITY_TYPE_TRADE, &orders)
+	if err != nil {
+		return nil, fmt.Errorf("error canceling open orders: %w", err)
+	}
+	return orders, nil
+}
+
+// Current Open Orders (USER_DATA)
+// https://binance-docs.github.io/apidocs/spot/en/#current-open-orders-user_data
+// Open orders for all symbols are returned when symbol is empty, at a much higher request weight.
+func (client Client) OpenOrders(symbol string) ([]entity.Order, error) {
+	params := url.Values{}
+	if symbol != "" {
+		params.Set("symbol", symbol)
+	}
+	var orders []entity.Order
+	err := client.call(http.MethodGet, c.PATH_OPEN_ORDERS, params, c.SECURITY_TYPE_USER_DATA, &orders)
+	if err != nil {
+		return nil, fmt.Errorf("error getting open orders: %w", err)
+	}
+	return orders, nil
+}
+
+// All Orders (USER_DATA)
+// https://binance-docs.github.io/apidocs/spot/en/#all-orders-user_data
+// Binance allows at most 24 hours between startTime and endTime and returns at most 1000 orders,
+// so the period is fetched in 24 hour windows, and pages of 1000 orders continued by order id.
+// A zero to means up to now.
+func (client Client) AllOrders(symbol string, from, to time.Time) ([]entity.Order, error) {
+	to, err := checkPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []entity.Order
+	for start := from; start.Before(to); start = start.Add(c.MAX_QUERY_WINDOW) {
+		end := start.Add(c.MAX_QUERY_WINDOW - time.Millisecond)
+		if end.After(to) {
+			end = to
+		}
+
+		params := url.Values{}
+		params.Set("symbol", symbol)
+		params.Set("startTime", strconv.FormatInt(start.UnixMilli(), 10))
+		params.Set("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+		params.Set("limit", strconv.Itoa(c.MAX_QUERY_LIMIT))
+		var page []entity.Order
+		err := client.call(http.MethodGet, c.PATH_ALL_ORDERS, params, c.SECURITY_TYPE_USER_DATA, &page)
+		if err != nil {
+			return nil, fmt.Errorf("error getting all orders: %w", err)
+		}
+		orders = append(orders, page...)
+
+		// Orders created in the same millisecond may span two pages, so the rest is paged by order id, not time
+		if len(page) == c.MAX_QUERY_LIMIT {
+			rest, err := client.allOrdersFromId(symbol, page[len(page)-1].OrderId+1, uint64(end.UnixMilli()))
+			if err != nil {
+				return nil, err
+			}
+			orders = append(orders, rest...)
+		}
+	}
+	return orders, nil
+}
+
+// allOrdersFromId pages through the orders from an order id on, up to and including the creation time until.
+func (client Client) allOrdersFromId(symbol string, orderId int64, until uint64) ([]entity.Order, error) {
+	var orders []entity.Order
+	for {
+		params := url.Values{}
+		params.Set("symbol", symbol)
+		params.Set("orderId", strconv.FormatInt(orderId, 10))
+		params.Set("limit", strconv.Itoa(c.MAX_QUERY_LIMIT))
+		var page []entity.Order
+		err := client.call(http.MethodGet, c.PATH_ALL_ORDERS, params, c.SECURITY_TYPE_USER_DATA, &page)
+		if err != nil {
+			return nil, fmt.Errorf("error getting all orders: %w", err)
+		}
+
+		for _, o := range page {
+			if o.Time > until {
+				return orders, nil
+			}
+			orders = append(orders, o)
+		}
+		if len(page) < c.MAX_QUERY_LIMIT {
+			return orders, nil
+		}
+		orderId = page[len(page)-1].OrderId + 1
+	}
+}
+
+// checkPeriod checks the period of a query, and returns its end, which is now if to is zero.
+func checkPeriod(from, to time.Time) (time.Time, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		return to, fmt.Errorf("the start of the period is required")
+	}
+	if !from.Before(to) {
+		return to, fmt.Errorf("the start of the period %v is not before its end %v", from, to)
+	}
+	return to, nil
+}
+
+// Account Trade List (USER_DATA)
+// https://binance-docs.github.io/apidocs/spot/en/#account-trade-list-user_data
+// With fromId > 0 all trades from that trade id on are returned and the period is ignored.
+// Otherwise the trades between from and to are returned, fetched in 24 hour windows,
+// and pages of 1000 trades continued by trade id, as the API doesn't allow fromId with a period.
+// A zero to means up to now.
+func (client Client) MyTrades(symbol string, from, to time.Time, fromId int64) ([]entity.Trade, error) {
+	if fromId > 0 {
+		return client.myTradesFromId(symbol, fromId, 0)
+	}
+	to, err := checkPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []entity.Trade
+	for start := from; start.Before(to); start = start.Add(c.MAX_QUERY_WINDOW) {
+		end := start.Add(c.MAX_QUERY_WINDOW - time.Millisecond)
+		if end.After(to) {
+			end = to
+		}
+
+		params := url.Values{}
+		params.Set("symbol", symbol)
+		params.Set("startTime", strconv.FormatInt(start.UnixMilli(), 10))
+		params.Set("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+		params.Set("limit", strconv.Itoa(c.MAX_QUERY_LIMIT))
+		var page []entity.Trade
+		err := client.call(http.MethodGet, c.PATH_MY_TRADES, params, c.SECURITY_TYPE_USER_DATA, &page)
+		if err != nil {
+			return nil, fmt.Errorf("error getting trades: %w", err)
+		}
+		trades = append(trades, page...)
+
+		if len(page) == c.MAX_QUERY_LIMIT {
+			rest, err := client.myTradesFromId(symbol, page[len(page)-1].Id+1, uint64(end.UnixMilli()))
+			if err != nil {
+				return nil, err
+			}
+			trades = append(trades, rest...)
+		}
+	}
+	return trades, nil
+}
+
+// myTradesFromId pages through the trades from a trade id on, up to and including the time until, if not 0.
+func (client Client) myTradesFromId(symbol string, fromId int64, until uint64) ([]entity.Trade, error) {
+	var trades []entity.Trade
+	for {
+		params := url.Values{}
+		params.Set("symbol", symbol)
+		params.Set("fromId", strconv.FormatInt(fromId, 10))
+		params.Set("limit", strconv.Itoa(c.MAX_QUERY_LIMIT))
+		var page []entity.Trade
+		err := client.call(http.MethodGet, c.PATH_MY_TRADES, params, c.SECURITY_TYPE_USER_DATA, &page)
+		if err != nil {
+			return nil, fmt.Errorf("error getting trades: %w", err)
+		}
+
+		for _, t := range page {
+			if until > 0 && t.Time > until {
+				return trades, nil
+			}
+			trades = append(trades, t)
+		}
+		if len(page) < c.MAX_QUERY_LIMIT {
+			return trades, nil
+		}
+		fromId = page[len(page)-1].Id + 1
+	}
+}
+
+// orderTrades returns the trades of an order.
+func (client Client) orderTrades(symbol string, orderId int64) ([]entity.Trade, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("orderId", strconv.FormatInt(orderId, 10))
+	var trades []entity.Trade
+	err := client.call(http.MethodGet, c.PATH_MY_TRADES, params, c.SECURITY_TYPE_USER_DATA, &trades)
+	if err != nil {
+		return nil, fmt.Errorf("error getting trades of order %d: %w", orderId, err)
+	}
+	return trades, nil
+}
+
+func orderParams(symbol string, orderId int64, origClientOrderId string) (url.Values, error) {
+	if orderId == 0 && origClientOrderId == "" {
+		return nil, fmt.Errorf("either orderId or origClientOrderId must be given")
+	}
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	if orderId != 0 {
+		params.Set("orderId", strconv.FormatInt(orderId, 10))
+	}
+	if origClientOrderId != "" {
+		params.Set("origClientOrderId", origClientOrderId)
+	}
+	return params, nil
+}
+
+// orderRequestParams converts an order request into the parameters of the New Order endpoint.
+func orderRequestParams(req entity.OrderRequest) url.Values {
+	params := url.Values{}
+	params.Set("symbol", req.Symbol)
+	params.Set("side", req.Side)
+	params.Set("type", req.Type)
+	if req.QuoteOrderQty > 0 {
+		params.Set("quoteOrderQty", util.Float2String(req.QuoteOrderQty))
+	} else {
+		params.Set("quantity", util.Float2String(req.Quantity))
+	}
+	if req.TimeInForce != "" {
+		params.Set("timeInForce", req.TimeInForce)
+	}
+	if req.Price > 0 {
+		params.Set("price", util.Float2String(req.Price))
+	}
+	if req.StopPrice > 0 {
+		params.Set("stopPrice", util.Float2String(req.StopPrice))
+	}
+	if req.IcebergQty > 0 {
+		params.Set("icebergQty", util.Float2String(req.IcebergQty))
+	}
+	if req.NewClientOrderId != "" {
+		params.Set("newClientOrderId", req.NewClientOrderId)
+	}
+	return params
+}
+
+// New OCO (TRADE)
+// https://binance-docs.github.io/apidocs/spot/en/#new-oco-trade
+// Both legs are validated against the symbol filters as separate orders.
+// There's no test endpoint, so it fails with ErrDryRunNotSupported in dry-run mode.
+func (client Client) PlaceOCO(req entity.OCORequest) (*entity.OrderList, error) {
+	if client.DryRun {
+		return nil, fmt.Errorf("error creating OCO order: %w", ErrDryRunNotSupported)
+	}
+	symbol, err := client.SymbolInfo(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if !symbol.OcoAllowed {
+		return nil, fmt.Errorf("OCO orders are not allowed on %s", req.Symbol)
+	}
+	if req.Side == c.SIDE_SELL && req.Price <= req.StopPrice {
+		return nil, fmt.Errorf("price must be above stopPrice for a %s OCO", c.SIDE_SELL)
+	}
+	if req.Side == c.SIDE_BUY && req.Price >= req.StopPrice {
+		return nil, fmt.Errorf("price must be below stopPrice for a %s OCO", c.SIDE_BUY)
+	}
+
+	limitLeg := entity.OrderRequest{
+		Symbol:   req.Symbol,
+		Side:     req.Side,
+		Type:     c.ORDER_TYPE_LIMIT_MAKER,
+		Quantity: req.Quantity,
+		Price:    req.Price,
+	}
+	stopLeg := entity.OrderRequest{
+		Symbol:    req.Symbol,
+		Side:      req.Side,
+		Type:      c.ORDER_TYPE_STOP_LOSS,
+		Quantity:  req.Quantity,
+		StopPrice: req.StopPrice,
+	}
+	if req.StopLimitPrice > 0 {
+		stopLeg.Type = c.ORDER_TYPE_STOP_LOSS_LIMIT
+		stopLeg.Price = req.StopLimitPrice
+		stopLeg.TimeInForce = req.StopLimitTimeInForce
+	}
+	for _, leg := range []*entity.OrderRequest{&limitLeg, &stopLeg} {
+		err = checkOrderRequest(*leg)
+		if err != nil {
+			return nil, fmt.Errorf("OCO %s leg: %w", leg.Type, err)
+		}
+		*leg, err = ValidateOrder(*symbol, *leg)
+		if err != nil {
+			return nil, fmt.Errorf("OCO %s leg: %w", leg.Type, err)
+		}
+	}
+
+	params := url.Values{}
+	params.Set("symbol", req.Symbol)
+	params.Set("side", req.Side)
+	params.Set("quantity", util.Float2String(limitLeg.Quantity))
+	params.Set("price", util.Float2String(limitLeg.Price))
+	params.Set("stopPrice", util.Float2String(stopLeg.StopPrice))
+	if stopLeg.Type == c.ORDER_TYPE_STOP_LOSS_LIMIT {
+		params.Set("stopLimitPrice", util.Float2String(stopLeg.Price))
+		params.Set("stopLimitTimeInForce", stopLeg.TimeInForce)
+	}
+	if req.ListClientOrderId != "" {
+		params.Set("listClientOrderId", req.ListClientOrderId)
+	}
+	params.Set("newOrderRespType", c.ORDER_RESP_TYPE_FULL)
+
+	var orderList entity.OrderList
+	err = client.call(http.MethodPost, c.PATH_ORDER_OCO, params, c.SECURITY_TYPE_TRADE, &orderList)
+	if err != nil {
+		return nil, fmt.Errorf("error creating OCO order: %w", err)
+	}
+	util.PP(orderList)
+	return &orderList, nil
+}
+
+// Cancel an Existing Order and Send a New Order (TRADE)
+// https://binance-docs.github.io/apidocs/spot/en/#cancel-an-existing-order-and-send-a-new-order-trade
+// Either cancelOrderId or cancelOrigClientOrderId must be given. The mode is STOP_ON_FAILURE or ALLOW_FAILURE.
+// There's no test endpoint, so it fails with ErrDryRunNotSupported in dry-run mode, set on the client or on the request.
+func (client Client) CancelReplace(cancelOrderId int64, cancelOrigClientOrderId string, req entity.OrderRequest, mode string) (*entity.CancelReplaceResp, error) {
+	if client.DryRun || req.DryRun {
+		return nil, fmt.Errorf("error in cancel-replace: %w", ErrDryRunNotSupported)
+	}
+	if cancelOrderId == 0 && cancelOrigClientOrderId == "" {
+		return nil, fmt.Errorf("either cancelOrderId or cancelOrigClientOrderId must be given")
+	}
+	if mode != c.CANCEL_REPLACE_STOP_ON_FAILURE && mode != c.CANCEL_REPLACE_ALLOW_FAILURE {
+		return nil, fmt.Errorf("unknown cancel-replace mode %q", mode)
+	}
+	if req.Type == "" {
+		req.Type = c.ORDER_TYPE_MARKET
+	}
+	err := checkOrderRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := client.SymbolInfo(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if !symbol.CancelReplaceAllowed {
+		return nil, fmt.Errorf("cancel-replace is not allowed on %s", req.Symbol)
+	}
+	req, err = ValidateOrder(*symbol, req)
+	if err != nil {
+		return nil, err
+	}
+
+	params := orderRequestParams(req)
+	params.Set("cancelReplaceMode", mode)
+	if cancelOrderId != 0 {
+		params.Set("cancelOrderId", strconv.FormatInt(cancelOrderId, 10))
+	}
+	if cancelOrigClientOrderId != "" {
+		params.Set("cancelOrigClientOrderId", cancelOrigClientOrderId)
+	}
+	params.Set("newOrderRespType", c.ORDER_RESP_TYPE_FULL)
+
+	var result entity.CancelReplaceResp
+	err = client.call(http.MethodPost, c.PATH_CANCEL_REPLACE, params, c.SECURITY_TYPE_TRADE, &result)
+	if err != nil {
+		return nil, fmt.Errorf("error in cancel-replace: %w", err)
+	}
+	util.PP(result)
+	return &result, nil
+}
+
 // --------------------------------------------------------------------------------
 // User
-func (client Client) AccountStatus() {
-	resp := client.Get(c.PATH_GET_ACCOUNT_STATUS, "")
+func (client Client) AccountStatus() (*entity.AccountStatusResp, error) {
+	resp, err := client.Get(c.PATH_GET_ACCOUNT_STATUS, "")
+	if err != nil {
+		return nil, fmt.Errorf("error getting account status: %w", err)
+	}
 	var decData entity.AccountStatusResp
-	decode(resp, &decData)
+	err = decode(resp, &decData)
+	if err != nil {
+		return nil, err
+	}
+	return &decData, nil
+}
+
+// Account Information (USER_DATA)
+// https://binance-docs.github.io/apidocs/spot/en/#account-information-user_data
+// Balances, permissions and commission rates. Assets with a zero balance are left out.
+func (client Client) Account() (*entity.AccountResp, error) {
+	params := url.Values{}
+	params.Set("omitZeroBalances", "true")
+	var account entity.AccountResp
+	err := client.call(http.MethodGet, c.PATH_ACCOUNT, params, c.SECURITY_TYPE_USER_DATA, &account)
+	if err != nil {
+		return nil, fmt.Errorf("error getting account: %w", err)
+	}
+	return &account, nil
+}
 
-	util.PP(decData)
+// Balance returns the free and locked amount of an asset, e.g. FDUSD.
+func (client Client) Balance(asset string) (free, locked float64, err error) {
+	account, err := client.Account()
+	if err != nil {
+		return 0, 0, err
+	}
+	b := account.Balance(asset)
+	return util.String2Float(b.Free), util.String2Float(b.Locked), nil
 }
 
 // Trade Fee (USER_DATA)
-// GET /sapi/v1/asset/tradeFee
+// https://binance-docs.github.io/apidocs/spot/en/#trade-fee-user_data
+// Fees for all symbols are returned when symbol is empty.
+func (client Client) TradeFee(symbol string) ([]entity.TradeFeeResp, error) {
+	params := url.Values{}
+	if symbol != "" {
+		params.Set("symbol", symbol)
+	}
+	var fees []entity.TradeFeeResp
+	err := client.call(http.MethodGet, c.PATH_TRADE_FEE, params, c.SECURITY_TYPE_USER_DATA, &fees)
+	if err != nil {
+		return nil, fmt.Errorf("error getting trade fee: %w", err)
+	}
+	return fees, nil
+}
 
 // Query User Wallet Balance (USER_DATA)
-// GET /sapi/v1/asset/wallet/balance
+// https://binance-docs.github.io/apidocs/spot/en/#query-user-wallet-balance-user_data
+// The balance of each wallet (Spot, Funding, ...) in BTC.
+func (client Client) WalletBalance() ([]entity.WalletBalanceResp, error) {
+	var balances []entity.WalletBalanceResp
+	err := client.call(http.MethodGet, c.PATH_WALLET_BALANCE, nil, c.SECURITY_TYPE_USER_DATA, &balances)
+	if err != nil {
+		return nil, fmt.Errorf("error getting wallet balance: %w", err)
+	}
+	return balances, nil
+}
+
+// Start user data stream (USER_STREAM)
+// https://binance-docs.github.io/apidocs/spot/en/#user-data-stream-endpoints
+// Returns a listenKey, valid for 60 minutes unless kept alive. An existing listenKey is returned if there is one.
+func (client Client) CreateListenKey() (string, error) {
+	var resp entity.ListenKeyResp
+	err := client.call(http.MethodPost, c.PATH_USER_DATA_STREAM, nil, c.SECURITY_TYPE_USER_STREAM, &resp)
+	if err != nil {
+		return "", fmt.Errorf("error creating listen key: %w", err)
+	}
+	return resp.ListenKey, nil
+}
+
+// Keepalive user data stream (USER_STREAM)
+// https://binance-docs.github.io/apidocs/spot/en/#user-data-stream-endpoints
+// Extends the validity of the listenKey to 60 minutes from now.
+func (client Client) KeepAliveListenKey(listenKey string) error {
+	params := url.Values{}
+	params.Set("listenKey", listenKey)
+	err := client.call(http.MethodPut, c.PATH_USER_DATA_STREAM, params, c.SECURITY_TYPE_USER_STREAM, &struct{}{})
+	if err != nil {
+		return fmt.Errorf("error keeping listen key alive: %w", err)
+	}
+	return nil
+}
+
+// Close user data stream (USER_STREAM)
+// https://binance-docs.github.io/apidocs/spot/en/#user-data-stream-endpoints
+func (client Client) CloseListenKey(listenKey string) error {
+	params := url.Values{}
+	params.Set("listenKey", listenKey)
+	err := client.call(http.MethodDelete, c.PATH_USER_DATA_STREAM, params, c.SECURITY_TYPE_USER_STREAM, &struct{}{})
+	if err != nil {
+		return fmt.Errorf("error closing listen key: %w", err)
+	}
+	return nil
+}
 
 // --------------------------------------------------------------------------------
 // System
 
 // Test Connectivity
 // https://binance-docs.github.io/apidocs/spot/en/#test-connectivity
-func (c Client) Ping() {
-	err := c.Conn.NewPingService().Do(context.Background())
+func (client Client) Ping() error {
+	host := client.Endpoints.Active()
+	_, err := client.Get(c.PATH_PING, "")
 	if err != nil {
-		code := regexp.MustCompile(`code=(\d+)`).FindStringSubmatch(err.Error())[1]
-		if code == "0" {
-			fmt.Printf("%s, no connection, quitting\n", c.BaseAPI)
-			os.Exit(0)
-		}
+		return fmt.Errorf("%s, no connection: %w", host, err)
 	}
-	fmt.Printf("%s, connection OK\n", c.BaseAPI)
+	fmt.Printf("%s, connection OK\n", host)
+	return nil
 }
 
 // Check Server Time
 // https://binance-docs.github.io/apidocs/spot/en/#check-server-time
-func (client Client) Time() {
-	resp := client.Get(c.PATH_TIME, "")
-	fmt.Println("resp:", string(resp))
-
+func (client Client) Time() (*entity.TimeResp, error) {
+	resp, err := client.Get(c.PATH_TIME, "")
+	if err != nil {
+		return nil, fmt.Errorf("error getting server time: %w", err)
+	}
 	var decData entity.TimeResp
-	decode(resp, &decData)
-
-	util.PP(decData)
+	err = decode(resp, &decData)
+	if err != nil {
+		return nil, err
+	}
+	return &decData, nil
 }
 
 // Symbol Price Ticker
 // https://binance-docs.github.io/apidocs/spot/en/#symbol-price-ticker
 // GET /api/v3/ticker/price
-func (c Client) SymbolPriceTicker(pair string) {
-	priceTicker, err := c.Conn.
-		NewTickerPriceService().
-		Symbol(pair).
-		Do(context.Background())
+func (client Client) SymbolPriceTicker(pair string) (*entity.PriceTickerResp, error) {
+	resp, err := client.Get(c.PATH_TICKER_PRICE, "symbol="+pair)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return nil, fmt.Errorf("error getting price ticker: %w", err)
+	}
+	var decData entity.PriceTickerResp
+	err = decode(resp, &decData)
+	if err != nil {
+		return nil, err
+	}
+	return &decData, nil
+}
+
+// Order Book
+// https://binance-docs.github.io/apidocs/spot/en/#order-book
+// A snapshot of the order book with up to limit levels per side, at most 5000. 0 means the default of 1000.
+func (client Client) Depth(symbol string, limit int) (*entity.DepthResp, error) {
+	if limit == 0 {
+		limit = c.DEPTH_LIMIT_DEFAULT
+	}
+	if limit < 0 || limit > c.DEPTH_LIMIT_MAX {
+		return nil, fmt.Errorf("depth limit %d is out of range 1-%d", limit, c.DEPTH_LIMIT_MAX)
 	}
-	fmt.Println(binance_connector.PrettyPrint(priceTicker))
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("limit", strconv.Itoa(limit))
+	var depth entity.DepthResp
+	err := client.call(http.MethodGet, c.PATH_DEPTH, params, c.SECURITY_TYPE_NONE, &depth)
+	if err != nil {
+		return nil, fmt.Errorf("error getting depth: %w", err)
+	}
+	return &depth, nil
+}
+
+// Kline/Candlestick intervals
+// https://binance-docs.github.io/apidocs/spot/en/#enum-definitions
+var klineIntervals = map[string]bool{
+	"1s": true, "1m": true, "3m": true, "5m": true, "15m": true, "30m": true,
+	"1h": true, "2h": true, "4h": true, "6h": true, "8h": true, "12h": true,
+	"1d": true, "3d": true, "1w": true, "1M": true,
 }
 
-func (client Client) ExchangeInfo(pair string) {
-	query := "symbol=" + pair
-	resp := client.Get(c.PATH_EXCHANGE_INFO, query)
+// Kline/Candlestick Data
+// https://binance-docs.github.io/apidocs/spot/en/#kline-candlestick-data
+// Returns the klines, e.g. with interval "1h", opened between start and end. See KlinePages.
+func (client Client) Klines(symbol, interval string, start, end time.Time) ([]entity.Kline, error) {
+	var klines []entity.Kline
+	err := client.KlinePages(symbol, interval, start, end, func(page []entity.Kline) error {
+		klines = append(klines, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return klines, nil
+}
+
+// KlinePages fetches the klines opened between start and end in pages of 1000, and passes each page to fn,
+// so long histories don't have to be kept in memory. A zero end means up to now.
+// The last kline may still be open, see Kline.CloseTime.
+func (client Client) KlinePages(symbol, interval string, start, end time.Time, fn func([]entity.Kline) error) error {
+	if !klineIntervals[interval] {
+		return fmt.Errorf("unknown kline interval %s", interval)
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	startMillis := start.UnixMilli()
+	for startMillis <= end.UnixMilli() {
+		params := url.Values{}
+		params.Set("symbol", symbol)
+		params.Set("interval", interval)
+		params.Set("startTime", strconv.FormatInt(startMillis, 10))
+		params.Set("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+		params.Set("limit", strconv.Itoa(c.MAX_QUERY_LIMIT))
+
+		var page []entity.Kline
+		err := client.call(http.MethodGet, c.PATH_KLINES, params, c.SECURITY_TYPE_NONE, &page)
+		if err != nil {
+			return fmt.Errorf("error getting klines: %w", err)
+		}
+		if len(page) == 0 {
+			return nil
+		}
+		err = fn(page)
+		if err != nil {
+			return err
+		}
+		if len(page) < c.MAX_QUERY_LIMIT {
+			return nil
+		}
+		startMillis = int64(page[len(page)-1].OpenTime) + 1
+	}
+	return nil
+}
+
+// Exchange Information
+// https://binance-docs.github.io/apidocs/spot/en/#exchange-information
+// All symbols are returned when pair is empty.
+func (client Client) ExchangeInfo(pair string) (*entity.ExchangeInfoRespX, error) {
+	query := ""
+	if pair != "" {
+		query = "symbol=" + pair
+	}
+	resp, err := client.Get(c.PATH_EXCHANGE_INFO, query)
+	if err != nil {
+		return nil, fmt.Errorf("error getting exchange info: %w", err)
+	}
 	var decData entity.ExchangeInfoRespX
-	decode(resp, &decData)
+	err = decode(resp, &decData)
+	if err != nil {
+		return nil, err
+	}
 	decData.ServerTimeStr = util.Time2String(decData.ServerTime)
+	return &decData, nil
+}
 
-	util.PP(decData)
+// SymbolInfo returns the exchange information for a single symbol, including its filters.
+// The information is served from the symbol registry.
+func (client Client) SymbolInfo(pair string) (*entity.SymbolInfo, error) {
+	return client.Symbols.Symbol(pair)
 }
 
 //--------------------------------------------------------------------------------
@@ -138,36 +1024,30 @@ func (client Client) ExchangeInfo(pair string) {
 
 // Individual Symbol Mini Ticker Stream
 // https://binance-docs.github.io/apidocs/spot/en/#individual-symbol-mini-ticker-stream
-func (client Client) StreamMiniTicker(symbols []string, quoteOrderQuantity, qty float64) {
-	fmt.Println("StreamMiniTicker", client.BaseWS, symbols)
-	isCombined := true
-	wsStreamClient := binance_connector.NewWebsocketStreamClient(isCombined, client.BaseWS)
-
-	wsHandler := func(e *binance_connector.WsMarketTickerStatEvent) {
-		// fmt.Println(binance_connector.PrettyPrint(e))
-		value := util.String2Float(e.LastPrice) * qty
-		profit := value - quoteOrderQuantity
-		// profitInPercents := (profit / quoteOrderQuantity) * 100
-		fmt.Printf("%s : %s : %v : %v : %v\n", e.Symbol, e.LastPrice, quoteOrderQuantity, value, profit)
-	}
+// The last price of each event is fed to the PnL engine, and the unrealised PnL of the position, if any, is printed.
+// It runs until the context is cancelled. Symbols can be added and removed with stream.Subscribe and
+// stream.Unsubscribe, using MiniTickerStream for the stream names, or all symbols followed with STREAM_ALL_MINI_TICKERS.
+func (client Client) StreamMiniTicker(ctx context.Context, stream *StreamManager, engine *pnl.Engine) {
+	fmt.Println("StreamMiniTicker", client.BaseWS, stream.Streams())
 
-	errHandler := func(err error) {
-		fmt.Println(err)
+	price := func(e entity.MiniTickerEvent) {
+		p, ok := engine.Price(e.Symbol, util.String2Float(e.Close))
+		if !ok {
+			fmt.Printf("%s : %s : no position\n", e.Symbol, e.Close)
+			return
+		}
+		fmt.Printf("%s : %s : qty:%v : avgPrice:%v : unrealised:%v : realised:%v\n", e.Symbol, e.Close, p.Qty, p.AvgPrice(), p.Unrealised, p.Realised)
 	}
 
-	doneCh, stopCh, err := wsStreamClient.WsCombinedMarketTickersStatServe(symbols, wsHandler, errHandler)
-	if err != nil {
-		fmt.Println("StreamMiniTicker", err)
-		return
+	for event := range stream.StartEvents(ctx) {
+		switch e := event.(type) {
+		case entity.MiniTickerEvent:
+			price(e)
+		case entity.AllMiniTickersEvent:
+			for _, t := range e {
+				price(t)
+			}
+		}
 	}
-
-	go func() {
-		time.Sleep(20 * time.Second)
-		fmt.Println("stopping stream...")
-		close(stopCh)
-		close(doneCh)
-	}()
-
-	done := <-doneCh
-	fmt.Println("done", done)
+	fmt.Println("stream stopped")
 }
@@ -1,36 +1,69 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
 	"time"
 
 	binance_connector "github.com/binance/binance-connector-go"
 	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+	"github.com/michelemendel/binance/journal"
+	"github.com/michelemendel/binance/pnl"
 	"github.com/michelemendel/binance/util"
 )
 
 type Client struct {
-	Env       string
-	Conn      *binance_connector.Client
-	APIKey    string
-	SecretKey string
-	Timeout   time.Duration
-	BaseAPI   string
-	BaseWS    string
+	Env         string
+	Conn        *binance_connector.Client
+	APIKey      string
+	SecretKey   string
+	Signer      Signer
+	Timeout     time.Duration
+	BaseAPI     string
+	BaseWS      string
+	Endpoints   *EndpointPool // The active REST API host, BaseAPI is the initial one
+	Symbols     *SymbolRegistry
+	RateLimiter *RateLimiter
+	TimeSync    *TimeSync
+	RecvWindow  time.Duration
+	Retry       RetryPolicy
+	DryRun      bool             // Orders are sent to the test endpoint and never executed
+	Journal     *journal.Journal // Orders placed are recorded here, if set
+	PnL         *pnl.Engine      // Fills of orders are added here, if set, see PnLEngine
+	WSAPI       *WSAPI           // Requests the WebSocket API has a method for are sent there instead of to the REST API, if set
 }
 
 func NewClient(env string, conn *binance_connector.Client, apiKey, secretKey, baseAPI, baseWS string) *Client {
-	return &Client{
-		Env:       env,
-		Conn:      conn,
-		APIKey:    apiKey,
-		SecretKey: secretKey,
-		Timeout:   c.TIMEOUT,
-		BaseAPI:   baseAPI,
-		BaseWS:    baseWS,
+	client := &Client{
+		Env:        env,
+		Conn:       conn,
+		APIKey:     apiKey,
+		SecretKey:  secretKey,
+		Signer:     HMACSigner{SecretKey: secretKey},
+		Timeout:    c.TIMEOUT,
+		RecvWindow: c.RECV_WINDOW,
+		Retry:      DefaultRetryPolicy(),
+		BaseAPI:    baseAPI,
+		BaseWS:     baseWS,
 	}
+	client.Endpoints = NewEndpointPool(baseAPI)
+	client.Symbols = NewSymbolRegistry(client, c.SYMBOL_REFRESH_INTERVAL)
+	client.RateLimiter = NewRateLimiter()
+	client.TimeSync = NewTimeSync(client, c.TIME_SYNC_INTERVAL)
+	return client
 }
+
+// WithRecvWindow returns a copy of the client that uses the recvWindow for signed requests, e.g.
+// client.WithRecvWindow(2 * time.Second).Order(req)
+func (client Client) WithRecvWindow(recvWindow time.Duration) *Client {
+	client.RecvWindow = recvWindow
+	return &client
+}
+
 func Run() {
 	var baseAPI string
 	var baseWS string
@@ -52,8 +85,62 @@ func Run() {
 
 	conn := binance_connector.NewClient(apiKey, secretKey, baseAPI)
 	client := NewClient(env, conn, apiKey, secretKey, baseAPI, baseWS)
-	client.Ping()
-	fmt.Printf("env:%s\nbaseAPI:%s\nbaseWS:%s\n", client.Env, client.BaseAPI, client.BaseWS)
+
+	// RSA and Ed25519 keys are read from a PKCS#8 PEM file, HMAC keys use the secret key
+	keyType := os.Getenv("KEY_TYPE")
+	if keyType != "" && keyType != c.KEY_TYPE_HMAC {
+		privateKeyPEM, err := os.ReadFile(os.Getenv("PRIVATE_KEY_PATH"))
+		if err != nil {
+			fmt.Println("error reading private key:", err)
+			return
+		}
+		client.Signer, err = NewSigner(keyType, secretKey, privateKeyPEM)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	client.DryRun = os.Getenv("DRY_RUN") == "true"
+
+	// Orders and account requests go over the WebSocket API instead of REST
+	if os.Getenv("WS_API") == "true" {
+		wsAPI := c.BASE_WS_PROD_3
+		if env == "test" {
+			wsAPI = c.BASE_WS_TEST
+		}
+		client.WSAPI = NewWSAPI(client, wsAPI)
+		defer client.WSAPI.Close()
+	}
+
+	journalPath := os.Getenv("JOURNAL_PATH")
+	if journalPath == "" {
+		journalPath = c.JOURNAL_PATH
+	}
+	j, err := journal.Open(journalPath)
+	if err != nil {
+		fmt.Println(err, "quitting")
+		return
+	}
+	defer j.Close()
+	client.Journal = j
+
+	// The testnet has a single host
+	if env != "test" {
+		client.Endpoints = NewEndpointPool(c.BASE_API_PROD_0, c.BASE_API_PROD_1, c.BASE_API_PROD_2, c.BASE_API_PROD_3, c.BASE_API_PROD_4)
+		err := client.Endpoints.HealthCheck()
+		if err != nil {
+			fmt.Println(err, "quitting")
+			return
+		}
+		client.Endpoints.Start(context.Background(), c.HEALTH_CHECK_INTERVAL)
+	}
+
+	err = client.Ping()
+	if err != nil {
+		fmt.Println(err, "quitting")
+		return
+	}
+	fmt.Printf("env:%s\nbaseAPI:%s\nbaseWS:%s\ndryRun:%v\nwsAPI:%v\n", client.Env, client.Endpoints.Active(), client.BaseWS, client.DryRun, client.WSAPI != nil)
 
 	// Buy/Sell
 	// qty := buy(client)
@@ -64,22 +151,113 @@ func Run() {
 	// client.AccountStatus()
 
 	// Streams
-	qty := 0.00136
-	quoteOrderQuantity := 100.0
 	// symbols := []string{"BTCFDUSD", "ETHFDUSD"}
 	symbols := []string{"BTCFDUSD"}
-	client.StreamMiniTicker(symbols, quoteOrderQuantity, qty)
+	engine, err := client.PnLEngine(c.COST_BASIS_FIFO, symbols)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	client.PnL = engine
+	// Runs until interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	// Fills of resting orders reach the journal and the engine through the execution reports
+	go func() {
+		for range client.UserDataStream().Start(ctx) {
+		}
+	}()
+	streams := make([]string, len(symbols))
+	for i, s := range symbols {
+		streams[i] = MiniTickerStream(s)
+	}
+	client.StreamMiniTicker(ctx, client.Stream(streams...), engine)
+
+}
+
+// PnLEngine returns a PnL engine with the positions in the symbols built from the account's trades,
+// which are synced to the journal first if the client has one. Set it as the client's PnL to add the fills
+// made from then on.
+func (client Client) PnLEngine(method string, symbols []string) (*pnl.Engine, error) {
+	engine, err := pnl.NewEngine(method, client.Symbols.Assets)
+	if err != nil {
+		return nil, err
+	}
+	for _, symbol := range symbols {
+		trades, err := client.accountTrades(symbol)
+		if err != nil {
+			return nil, err
+		}
+		err = client.addFills(engine, trades)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return engine, nil
+}
+
+// accountTrades returns all the account's trades in a symbol, from the journal if the client has one.
+func (client Client) accountTrades(symbol string) ([]entity.Trade, error) {
+	if client.Journal == nil {
+		return client.MyTrades(symbol, time.Time{}, time.Time{}, 1)
+	}
+	_, err := client.SyncFills(symbol)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := client.Journal.Fills(symbol, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	var trades []entity.Trade
+	for _, e := range entries {
+		if e.Env == client.Env {
+			trades = append(trades, e.Trade)
+		}
+	}
+	return trades, nil
+}
 
+// addFills adds trades to a PnL engine. Commissions in an asset other than the symbol's, e.g. BNB,
+// are converted at the asset's current price, since the trades don't have its price at the time.
+func (client Client) addFills(engine *pnl.Engine, trades []entity.Trade) error {
+	fetched := map[string]bool{}
+	for _, t := range trades {
+		base, quote, err := client.Symbols.Assets(t.Symbol)
+		if err != nil {
+			return err
+		}
+		pair := t.CommissionAsset + quote
+		if t.CommissionAsset != "" && t.CommissionAsset != base && t.CommissionAsset != quote && !fetched[pair] {
+			fetched[pair] = true
+			ticker, err := client.SymbolPriceTicker(pair)
+			if err != nil {
+				// The engine keeps the commission as unconverted
+				slog.Warn("error getting price to convert commission", "pair", pair, "error", err)
+			} else {
+				engine.Price(pair, util.String2Float(ticker.Price))
+			}
+		}
+		_, err = engine.Add(pnl.FillFromTrade(t))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func buy(client *Client) float64 {
 	symbol := "BTCFDUSD"
 	quoteOrderQuantity := 100.0
-	order, err := client.Buy(symbol, quoteOrderQuantity, 0)
+	order, err := client.Buy(entity.OrderRequest{Symbol: symbol, QuoteOrderQty: quoteOrderQuantity})
 	if err != nil {
 		fmt.Println(err)
 		return 0
 	}
+	if len(order.Fills) == 0 {
+		fmt.Printf("no fills for %s (dryRun:%v)\n", symbol, order.DryRun)
+		return 0
+	}
 	qty := util.String2Float(order.ExecutedQty)
 	price := util.String2Float(order.Fills[0].Price)
 	fmt.Printf("bought:%s, price:%v for %v, received amount:%v\n", symbol, price, quoteOrderQuantity, qty)
@@ -88,11 +266,15 @@ func buy(client *Client) float64 {
 
 func sell(client *Client, qty float64) {
 	symbol := "BTCFDUSD"
-	order, err := client.Sell(symbol, 0, qty)
+	order, err := client.Sell(entity.OrderRequest{Symbol: symbol, Quantity: qty})
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
+	if len(order.Fills) == 0 {
+		fmt.Printf("no fills for %s (dryRun:%v)\n", symbol, order.DryRun)
+		return
+	}
 	exQty := util.String2Float(order.ExecutedQty)
 	price := util.String2Float(order.Fills[0].Price)
 	total := exQty * price
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+)
+
+// EndpointPool picks the REST API host with the lowest latency, and fails over to the next one
+// on connection errors and 5xx responses.
+type EndpointPool struct {
+	mu     sync.RWMutex
+	hosts  []string // Sorted by latency after a health check
+	active string
+	stats  map[string]*entity.EndpointStatus
+}
+
+func NewEndpointPool(hosts ...string) *EndpointPool {
+	stats := map[string]*entity.EndpointStatus{}
+	for _, h := range hosts {
+		stats[h] = &entity.EndpointStatus{Host: h, Healthy: true}
+	}
+	return &EndpointPool{
+		hosts:  hosts,
+		active: hosts[0],
+		stats:  stats,
+	}
+}
+
+// Active returns the host requests are sent to.
+func (p *EndpointPool) Active() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.active
+}
+
+// HealthCheck pings all hosts, orders them by latency and makes the fastest healthy host active.
+func (p *EndpointPool) HealthCheck() error {
+	p.mu.RLock()
+	hosts := append([]string{}, p.hosts...)
+	p.mu.RUnlock()
+
+	type result struct {
+		host    string
+		latency time.Duration
+		err     error
+	}
+	results := make(chan result, len(hosts))
+	for _, h := range hosts {
+		go func(host string) {
+			latency, err := ping(host)
+			results <- result{host, latency, err}
+		}(h)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for range hosts {
+		r := <-results
+		s := p.stats[r.host]
+		s.CheckedAt = now
+		s.Latency = r.latency
+		s.Healthy = r.err == nil
+		if r.err != nil {
+			slog.Warn("endpoint unhealthy", "host", r.host, "error", r.err)
+		}
+	}
+
+	sort.SliceStable(p.hosts, func(i, j int) bool {
+		a, b := p.stats[p.hosts[i]], p.stats[p.hosts[j]]
+		if a.Healthy != b.Healthy {
+			return a.Healthy
+		}
+		return a.Latency < b.Latency
+	})
+	if !p.stats[p.hosts[0]].Healthy {
+		return fmt.Errorf("no healthy endpoint among %v", p.hosts)
+	}
+	p.setActive(p.hosts[0], "health check")
+	return nil
+}
+
+// Start runs health checks periodically until the context is cancelled.
+func (p *EndpointPool) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := p.HealthCheck()
+				if err != nil {
+					slog.Error("endpoint health check failed", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Failover marks the host as failed and, if it's the active host, moves to the next healthy host.
+func (p *EndpointPool) Failover(host string, cause error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.stats[host]
+	if !ok {
+		return
+	}
+	s.Failures++
+	s.Healthy = false
+	s.LastError = cause.Error()
+	if host != p.active || len(p.hosts) == 1 {
+		return
+	}
+
+	// The next host in latency order, preferring healthy ones
+	next := ""
+	for i := 1; i < len(p.hosts); i++ {
+		h := p.hosts[(p.indexOf(host)+i)%len(p.hosts)]
+		if p.stats[h].Healthy {
+			next = h
+			break
+		}
+		if next == "" {
+			next = h
+		}
+	}
+	p.setActive(next, cause.Error())
+}
+
+// Status returns the health of all hosts, e.g. for display.
+func (p *EndpointPool) Status() []entity.EndpointStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	status := make([]entity.EndpointStatus, len(p.hosts))
+	for i, h := range p.hosts {
+		status[i] = *p.stats[h]
+		status[i].Active = h == p.active
+	}
+	return status
+}
+
+func (p *EndpointPool) setActive(host, reason string) {
+	if host == p.active {
+		return
+	}
+	slog.Warn("switching endpoint", "from", p.active, "to", host, "reason", reason)
+	p.active = host
+	p.stats[host].Switches++
+}
+
+func (p *EndpointPool) indexOf(host string) int {
+	for i, h := range p.hosts {
+		if h == host {
+			return i
+		}
+	}
+	return 0
+}
+
+func ping(host string) (time.Duration, error) {
+	httpClient := &http.Client{Timeout: c.TIMEOUT}
+	start := time.Now()
+	resp, err := httpClient.Get(host + c.PATH_PING)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	latency := time.Since(start)
+	if resp.StatusCode != http.StatusOK {
+		return latency, fmt.Errorf("ping returned %s", resp.Status)
+	}
+	return latency, nil
+}
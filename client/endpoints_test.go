@@ -0,0 +1,150 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+)
+
+// newHost starts a REST API host that answers ping and time after a delay, or with status if it isn't 200.
+func newHost(t *testing.T, delay time.Duration, status int) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{"code":-1000,"msg":"Service unavailable"}`)
+			return
+		}
+		switch r.URL.Path {
+		case c.PATH_PING:
+			fmt.Fprint(w, `{}`)
+		case c.PATH_TIME:
+			fmt.Fprintf(w, `{"serverTime":%d}`, time.Now().UnixMilli())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// closedHost returns the URL of a host that refuses connections.
+func closedHost() string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	return server.URL
+}
+
+func TestEndpointPoolHealthCheck(t *testing.T) {
+	fast := newHost(t, 0, http.StatusOK)
+	slow := newHost(t, 30*time.Millisecond, http.StatusOK)
+	failing := newHost(t, 0, http.StatusServiceUnavailable)
+	closed := closedHost()
+
+	p := NewEndpointPool(closed, slow, failing, fast)
+	err := p.HealthCheck()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Active() != fast {
+		t.Errorf("active: got %s, want the fastest %s", p.Active(), fast)
+	}
+
+	// Healthy hosts by latency, then the unhealthy ones
+	status := p.Status()
+	for i, want := range []struct {
+		host    string
+		healthy bool
+	}{{fast, true}, {slow, true}, {closed, false}, {failing, false}} {
+		s := status[i]
+		if s.Host != want.host || s.Healthy != want.healthy || s.Active != (i == 0) || s.CheckedAt.IsZero() {
+			t.Errorf("status %d: got %+v, want host %s, healthy %v", i, s, want.host, want.healthy)
+		}
+	}
+	if status[0].Latency >= status[1].Latency {
+		t.Errorf("latency: got %v for the fast host and %v for the slow one", status[0].Latency, status[1].Latency)
+	}
+
+	p = NewEndpointPool(closed, failing)
+	if err := p.HealthCheck(); err == nil {
+		t.Error("no healthy host: got no error")
+	}
+}
+
+func TestEndpointPoolFailover(t *testing.T) {
+	hosts := []string{"https://a", "https://b", "https://c"}
+	cause := errors.New("connection refused")
+	tests := []struct {
+		name   string
+		active string
+		failed []string // In order
+		want   string   // Active host
+	}{
+		{"next host", "https://a", []string{"https://a"}, "https://b"},
+		{"not the active host", "https://a", []string{"https://b"}, "https://a"},
+		{"skips unhealthy hosts", "https://a", []string{"https://b", "https://a"}, "https://c"},
+		{"wraps around", "https://c", []string{"https://c"}, "https://a"},
+		{"wraps around, skips unhealthy hosts", "https://c", []string{"https://a", "https://c"}, "https://b"},
+		{"all unhealthy, next in order", "https://a", []string{"https://a", "https://b", "https://c"}, "https://a"},
+		{"unknown host", "https://a", []string{"https://x"}, "https://a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewEndpointPool(hosts...)
+			p.active = tt.active
+			for _, h := range tt.failed {
+				p.Failover(h, cause)
+			}
+			if p.Active() != tt.want {
+				t.Errorf("active: got %s, want %s", p.Active(), tt.want)
+			}
+		})
+	}
+
+	p := NewEndpointPool(hosts[0])
+	p.Failover(hosts[0], cause)
+	if s := p.Status()[0]; p.Active() != hosts[0] || s.Healthy || s.Failures != 1 || s.LastError != cause.Error() {
+		t.Errorf("single host: got %+v", s)
+	}
+}
+
+func TestClientFailover(t *testing.T) {
+	failing := newHost(t, 0, http.StatusServiceUnavailable)
+	closed := closedHost()
+	good := newHost(t, 0, http.StatusOK)
+
+	cl := NewClient("test", nil, "", "", failing, "")
+	cl.Retry = testRetry
+	cl.Endpoints = NewEndpointPool(failing, closed, good)
+
+	// The 5xx moves to the closed host, and its connection error to the good one
+	_, err := cl.Get(c.PATH_TIME, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cl.Endpoints.Active() != good {
+		t.Errorf("active: got %s, want %s", cl.Endpoints.Active(), good)
+	}
+	for i, want := range []struct {
+		failures, switches int
+	}{{1, 0}, {1, 1}, {0, 1}} {
+		s := cl.Endpoints.Status()[i]
+		if s.Failures != want.failures || s.Switches != want.switches {
+			t.Errorf("status of %s: got %+v, want %d failures and %d switches", s.Host, s, want.failures, want.switches)
+		}
+	}
+
+	// A 4xx is the request's fault, not the host's
+	_, err = cl.Get("/api/v3/unknown", "")
+	if err == nil {
+		t.Fatal("unknown endpoint: got no error")
+	}
+	if s := cl.Endpoints.Status()[2]; cl.Endpoints.Active() != good || s.Failures != 0 {
+		t.Errorf("after a 404: got %+v", s)
+	}
+}
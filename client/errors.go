@@ -0,0 +1,62 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrDryRunNotSupported is returned in dry-run mode by the order endpoints that Binance has no test endpoint for,
+// e.g. OCO and cancel-replace, rather than sending the order for real.
+var ErrDryRunNotSupported = errors.New("not supported in dry-run mode, there's no test endpoint")
+
+// APIError is an error response from Binance, e.g. {"code":-1121,"msg":"Invalid symbol."}
+// https://binance-docs.github.io/apidocs/spot/en/#error-codes
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       int    `json:"code"`
+	Msg        string `json:"msg"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("<APIError> status=%d, code=%d, msg=%s", e.StatusCode, e.Code, e.Msg)
+}
+
+func newAPIError(statusCode int, body []uint8) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+	err := json.Unmarshal(body, apiErr)
+	if err != nil || apiErr.Msg == "" {
+		// Not all errors have a Binance body, e.g. a 502 from a proxy
+		apiErr.Msg = http.StatusText(statusCode)
+		if len(body) > 0 {
+			apiErr.Msg = string(body)
+		}
+	}
+	return apiErr
+}
+
+// IsAPIError reports whether err is, or wraps, an *APIError with one of the given codes.
+// Without codes it reports whether err is an *APIError at all.
+func IsAPIError(err error, codes ...int) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if len(codes) == 0 {
+		return true
+	}
+	for _, code := range codes {
+		if apiErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsServerError reports whether err is an *APIError caused by a problem on Binance's side (5xx).
+// The request may or may not have been executed.
+func IsServerError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= http.StatusInternalServerError
+}
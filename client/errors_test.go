@@ -0,0 +1,69 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   APIError
+	}{
+		{"binance error", http.StatusBadRequest, `{"code":-1121,"msg":"Invalid symbol."}`, APIError{http.StatusBadRequest, -1121, "Invalid symbol."}},
+		{"code without msg", http.StatusBadRequest, `{"code":-1000}`, APIError{http.StatusBadRequest, -1000, `{"code":-1000}`}},
+		{"html from a proxy", http.StatusBadGateway, "<html>Bad Gateway</html>", APIError{http.StatusBadGateway, 0, "<html>Bad Gateway</html>"}},
+		{"no body", http.StatusServiceUnavailable, "", APIError{http.StatusServiceUnavailable, 0, "Service Unavailable"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newAPIError(tt.status, []uint8(tt.body))
+			if *got != tt.want {
+				t.Errorf("newAPIError() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAPIError(t *testing.T) {
+	apiErr := newAPIError(http.StatusBadRequest, []uint8(`{"code":-2010,"msg":"Account has insufficient balance for requested action."}`))
+	wrapped := fmt.Errorf("error placing order: %w", fmt.Errorf("error making request: %w", apiErr))
+	serverErr := fmt.Errorf("error getting account: %w", newAPIError(http.StatusServiceUnavailable, nil))
+
+	tests := []struct {
+		name       string
+		err        error
+		codes      []int
+		want       bool
+		wantServer bool
+	}{
+		{"any code", apiErr, nil, true, false},
+		{"code", apiErr, []int{-2010}, true, false},
+		{"one of the codes", apiErr, []int{-1021, -2010}, true, false},
+		{"other code", apiErr, []int{-1021}, false, false},
+		{"wrapped", wrapped, []int{-2010}, true, false},
+		{"wrapped, other code", wrapped, []int{-2011}, false, false},
+		{"server error", serverErr, nil, true, true},
+		{"not an API error", errors.New("connection refused"), nil, false, false},
+		{"nil", nil, nil, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAPIError(tt.err, tt.codes...); got != tt.want {
+				t.Errorf("IsAPIError() = %v, want %v", got, tt.want)
+			}
+			if got := IsServerError(tt.err); got != tt.wantServer {
+				t.Errorf("IsServerError() = %v, want %v", got, tt.wantServer)
+			}
+		})
+	}
+
+	var target *APIError
+	if !errors.As(wrapped, &target) || target.Code != -2010 || target.StatusCode != http.StatusBadRequest {
+		t.Errorf("errors.As() = %+v", target)
+	}
+}
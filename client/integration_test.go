@@ -0,0 +1,308 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+	"github.com/michelemendel/binance/fakebinance"
+	"github.com/michelemendel/binance/journal"
+	"github.com/michelemendel/binance/pnl"
+)
+
+func newFakeBinance(t *testing.T) (*fakebinance.Server, *Client) {
+	server := fakebinance.NewServer()
+	t.Cleanup(server.Close)
+	server.APIKey = "apikey"
+	server.SecretKey = secretKey
+	server.AddSymbol("BTCFDUSD", "BTC", "FDUSD", 40000)
+	server.AddSymbol("ETHFDUSD", "ETH", "FDUSD", 2000)
+
+	cl := NewClient("test", nil, "apikey", secretKey, server.URL, server.WSURL())
+	cl.Retry = testRetry
+	return server, cl
+}
+
+func TestExchangeInfo(t *testing.T) {
+	server, cl := newFakeBinance(t)
+
+	// Retried after the server error
+	server.Script(http.MethodGet, c.PATH_EXCHANGE_INFO, fakebinance.Error(http.StatusServiceUnavailable, c.ERROR_CODE_UNKNOWN, "Service unavailable"))
+	info, err := cl.ExchangeInfo("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.Requests(http.MethodGet, c.PATH_EXCHANGE_INFO)); n != 2 {
+		t.Errorf("requests: got %d, want 2", n)
+	}
+	if len(info.Symbols) != 2 || info.Symbols[0].Symbol != "BTCFDUSD" || len(info.RateLimits) != 3 {
+		t.Errorf("exchange info: got %d symbols and %d rate limits", len(info.Symbols), len(info.RateLimits))
+	}
+
+	symbol, err := cl.SymbolInfo("ETHFDUSD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if symbol.BaseAsset != "ETH" || symbol.Filter(c.FILTER_LOT_SIZE) == nil {
+		t.Errorf("symbol info: got %+v", symbol)
+	}
+
+	_, err = cl.ExchangeInfo("XRPFDUSD")
+	if !IsAPIError(err, c.ERROR_CODE_BAD_SYMBOL) {
+		t.Errorf("unknown symbol: got %v, want code %d", err, c.ERROR_CODE_BAD_SYMBOL)
+	}
+}
+
+func TestBuySell(t *testing.T) {
+	server, cl := newFakeBinance(t)
+	server.SetBalance("FDUSD", 1000)
+
+	bought, err := cl.Buy(entity.OrderRequest{Symbol: "BTCFDUSD", QuoteOrderQty: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bought.Status != "FILLED" || bought.ExecutedQty != "0.00250000" || len(bought.Fills) != 1 {
+		t.Errorf("buy: got %+v", bought)
+	}
+	if btc, fdusd := server.Balance("BTC"), server.Balance("FDUSD"); btc != 0.0025 || fdusd != 900 {
+		t.Errorf("balances after buy: got %v BTC and %v FDUSD", btc, fdusd)
+	}
+	free, _, err := cl.Balance("BTC")
+	if err != nil || free != 0.0025 {
+		t.Errorf("BTC balance: got %v, %v", free, err)
+	}
+
+	// The order fails with a server error, isn't found, and is sent again
+	server.SetPrice("BTCFDUSD", 44000)
+	server.Script(http.MethodPost, c.PATH_ORDER, fakebinance.Error(http.StatusServiceUnavailable, c.ERROR_CODE_UNKNOWN, "Service unavailable"))
+	sold, err := cl.Sell(entity.OrderRequest{Symbol: "BTCFDUSD", Quantity: 0.0025})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sold.Status != "FILLED" || sold.CummulativeQuoteQty != "110.00000000" {
+		t.Errorf("sell: got %+v", sold)
+	}
+	orders := server.Requests(http.MethodPost, c.PATH_ORDER)
+	if len(orders) != 3 || orders[1].Params.Get("newClientOrderId") != orders[2].Params.Get("newClientOrderId") {
+		t.Errorf("order requests: got %d, want 3 with the same client order id for the last two", len(orders))
+	}
+	if btc, fdusd := server.Balance("BTC"), server.Balance("FDUSD"); btc != 0 || fdusd != 1010 {
+		t.Errorf("balances after sell: got %v BTC and %v FDUSD", btc, fdusd)
+	}
+
+	// Rejected orders aren't retried
+	_, err = cl.Sell(entity.OrderRequest{Symbol: "BTCFDUSD", Quantity: 1})
+	if !IsAPIError(err, c.ERROR_CODE_NEW_ORDER_REJECTED) {
+		t.Errorf("sell without balance: got %v, want code %d", err, c.ERROR_CODE_NEW_ORDER_REJECTED)
+	}
+	if n := len(server.Requests(http.MethodPost, c.PATH_ORDER)); n != 4 {
+		t.Errorf("order requests: got %d, want 4", n)
+	}
+}
+
+func TestJournalFills(t *testing.T) {
+	server, cl := newFakeBinance(t)
+	server.SetBalance("FDUSD", 1000)
+	server.SetBalance("BTC", 1)
+	j, err := journal.Open(filepath.Join(t.TempDir(), "journal.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	cl.Journal = j
+
+	// The first sync starts from the account's first trade
+	synced, err := cl.SyncFills("BTCFDUSD")
+	if err != nil || len(synced) != 0 {
+		t.Fatalf("first sync: got %v, %v", synced, err)
+	}
+
+	// Filled when placed
+	_, err = cl.Buy(entity.OrderRequest{Symbol: "BTCFDUSD", QuoteOrderQty: 100, Strategy: "dca"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Filled later, found by the sync
+	resting, err := cl.Sell(entity.OrderRequest{Symbol: "BTCFDUSD", Type: c.ORDER_TYPE_LIMIT, TimeInForce: c.TIME_IN_FORCE_GTC, Quantity: 0.001, Price: 50000, Strategy: "grid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !server.FillOrder("BTCFDUSD", resting.OrderId) {
+		t.Fatal("resting order not filled")
+	}
+	// The fill made when placed is synced too, but recorded once
+	synced, err = cl.SyncFills("BTCFDUSD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(synced) != 2 || synced[1].OrderId != resting.OrderId {
+		t.Errorf("synced: got %+v, want the fill of order %d last", synced, resting.OrderId)
+	}
+
+	// Placed, but the response is lost, so the fills are taken from the trade list
+	server.Script(http.MethodPost, c.PATH_ORDER, fakebinance.Response{Status: http.StatusServiceUnavailable, Executed: true})
+	lost, err := cl.Buy(entity.OrderRequest{Symbol: "BTCFDUSD", QuoteOrderQty: 50, Strategy: "dca"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lost.Fills) != 1 || lost.Fills[0].Qty != lost.ExecutedQty {
+		t.Errorf("fills of the order found after the lost response: got %+v", lost.Fills)
+	}
+
+	fills, err := j.Fills("BTCFDUSD", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range fills {
+		got = append(got, f.Strategy+":"+f.Trade.Qty)
+	}
+	want := []string{"dca:0.00250000", "grid:0.00100000", "dca:0.00125000"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("journaled fills: got %v, want %v", got, want)
+	}
+
+	// Only the fill of the last order is new
+	synced, err = cl.SyncFills("BTCFDUSD")
+	if err != nil || len(synced) != 1 || synced[0].OrderId != lost.OrderId {
+		t.Errorf("last sync: got %v, %v", synced, err)
+	}
+}
+
+func TestPnLEngine(t *testing.T) {
+	server, cl := newFakeBinance(t)
+	server.AddSymbol("BNBFDUSD", "BNB", "FDUSD", 500)
+	server.SetBalance("FDUSD", 1000)
+	j, err := journal.Open(filepath.Join(t.TempDir(), "journal.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	cl.Journal = j
+
+	// Bought 0.01 at 30000 and sold 0.005 at 35000 before, with commissions in BNB
+	server.AddTrade(entity.Trade{Symbol: "BTCFDUSD", Price: "30000", Qty: "0.01", QuoteQty: "300", Commission: "0.001", CommissionAsset: "BNB", IsBuyer: true})
+	server.AddTrade(entity.Trade{Symbol: "BTCFDUSD", Price: "35000", Qty: "0.005", QuoteQty: "175", Commission: "0.001", CommissionAsset: "BNB"})
+	engine, err := cl.PnLEngine(c.COST_BASIS_FIFO, []string{"BTCFDUSD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl.PnL = engine
+	p, _ := engine.Position("BTCFDUSD")
+	// The commissions are converted at the BNB price of 500
+	if !approx(p.Qty, 0.005) || !approx(p.Commission, 1) || !approx(p.Realised, 175-0.5-150.25) || len(p.Unconverted) != 0 {
+		t.Errorf("position from the trade list: got %+v", p)
+	}
+
+	// Filled when placed
+	_, err = cl.Buy(entity.OrderRequest{Symbol: "BTCFDUSD", QuoteOrderQty: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Filled later, and synced twice
+	resting, err := cl.Sell(entity.OrderRequest{Symbol: "BTCFDUSD", Type: c.ORDER_TYPE_LIMIT, TimeInForce: c.TIME_IN_FORCE_GTC, Quantity: 0.01, Price: 45000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.FillOrder("BTCFDUSD", resting.OrderId)
+	for i := 0; i < 2; i++ {
+		_, err = cl.SyncFills("BTCFDUSD")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, _ = engine.Position("BTCFDUSD")
+	if !approx(p.Qty, 0) || !approx(p.Realised, 175-0.5-150.25+450-150.25-200) {
+		t.Errorf("position after the orders: got %+v", p)
+	}
+}
+
+func approx(got, want float64) bool {
+	return math.Abs(got-want) < 1e-9
+}
+
+func TestDryRun(t *testing.T) {
+	server, cl := newFakeBinance(t)
+	server.SetBalance("FDUSD", 1000)
+	cl.DryRun = true
+
+	// Orders go to the test endpoint
+	_, err := cl.Buy(entity.OrderRequest{Symbol: "BTCFDUSD", QuoteOrderQty: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.Requests(http.MethodPost, c.PATH_ORDER)); n != 0 {
+		t.Errorf("order requests: got %d, want 0", n)
+	}
+	if fdusd := server.Balance("FDUSD"); fdusd != 1000 {
+		t.Errorf("FDUSD balance: got %v, want 1000", fdusd)
+	}
+
+	// OCO and cancel-replace have no test endpoint, and aren't sent at all
+	sent := len(server.Requests("", ""))
+	_, err = cl.PlaceOCO(entity.OCORequest{Symbol: "BTCFDUSD", Side: c.SIDE_SELL, Quantity: 0.001, Price: 45000, StopPrice: 35000})
+	if !errors.Is(err, ErrDryRunNotSupported) {
+		t.Errorf("OCO: got %v, want %v", err, ErrDryRunNotSupported)
+	}
+	_, err = cl.CancelReplace(1, "", entity.OrderRequest{Symbol: "BTCFDUSD", Side: c.SIDE_BUY, Quantity: 0.001}, c.CANCEL_REPLACE_STOP_ON_FAILURE)
+	if !errors.Is(err, ErrDryRunNotSupported) {
+		t.Errorf("cancel-replace: got %v, want %v", err, ErrDryRunNotSupported)
+	}
+	if n := len(server.Requests("", "")); n != sent {
+		t.Errorf("requests: got %d, want %d", n, sent)
+	}
+}
+
+func TestStreamMiniTicker(t *testing.T) {
+	server, cl := newFakeBinance(t)
+	engine, err := pnl.NewEngine(c.COST_BASIS_FIFO, func(symbol string) (string, string, error) {
+		return "BTC", "FDUSD", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = engine.Add(pnl.Fill{Symbol: "BTCFDUSD", Side: c.SIDE_BUY, Price: 40000, Qty: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cl.StreamMiniTicker(ctx, cl.Stream(MiniTickerStream("BTCFDUSD")), engine)
+	}()
+
+	// Sets the price until the ticker brings it to the engine
+	waitForPrice := func(price float64) {
+		t.Helper()
+		for ctx.Err() == nil {
+			server.SetPrice("BTCFDUSD", price)
+			time.Sleep(10 * time.Millisecond)
+			if p, _ := engine.Position("BTCFDUSD"); p.LastPrice == price {
+				if p.Unrealised != price-40000 {
+					t.Errorf("unrealised at %v: got %v", price, p.Unrealised)
+				}
+				return
+			}
+		}
+		t.Fatalf("price %v not received", price)
+	}
+	waitForPrice(41000)
+
+	// Resubscribed after reconnecting
+	server.DropStreams()
+	waitForPrice(42000)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+)
+
+// Names of the market streams, and decoding of their events into the types implementing entity.MarketEvent
+// https://binance-docs.github.io/apidocs/spot/en/#websocket-market-streams
+
+func AggTradeStream(symbol string) string {
+	return strings.ToLower(symbol) + "@aggTrade"
+}
+
+func TradeStream(symbol string) string {
+	return strings.ToLower(symbol) + "@trade"
+}
+
+// KlineStream returns the name of the kline stream of a symbol, with interval e.g. "1m".
+func KlineStream(symbol, interval string) string {
+	return strings.ToLower(symbol) + "@kline_" + interval
+}
+
+func BookTickerStream(symbol string) string {
+	return strings.ToLower(symbol) + "@bookTicker"
+}
+
+// PartialDepthStream returns the name of the stream of the top 5, 10 or 20 levels of the book,
+// pushed every 100ms if fast, otherwise every second.
+func PartialDepthStream(symbol string, levels int, fast bool) string {
+	return depthStream(fmt.Sprintf("%s@depth%d", strings.ToLower(symbol), levels), fast)
+}
+
+// DepthStream returns the name of the diff depth stream of a symbol, pushed every 100ms if fast, otherwise every second.
+func DepthStream(symbol string, fast bool) string {
+	return depthStream(strings.ToLower(symbol)+"@depth", fast)
+}
+
+func depthStream(name string, fast bool) string {
+	if fast {
+		return name + "@100ms"
+	}
+	return name
+}
+
+func AvgPriceStream(symbol string) string {
+	return strings.ToLower(symbol) + "@avgPrice"
+}
+
+func TickerStream(symbol string) string {
+	return strings.ToLower(symbol) + "@ticker"
+}
+
+// MiniTickerStream returns the name of the mini ticker stream of a symbol, e.g. btcfdusd@miniTicker.
+func MiniTickerStream(symbol string) string {
+	return strings.ToLower(symbol) + "@miniTicker"
+}
+
+// DecodeMarketEvent decodes the event of a stream into its type, which is found from the stream name.
+func DecodeMarketEvent(msg StreamMessage) (entity.MarketEvent, error) {
+	symbol, kind, _ := strings.Cut(msg.Stream, "@")
+	switch {
+	case msg.Stream == c.STREAM_ALL_TICKERS:
+		return decodeEvent[entity.AllTickersEvent](msg)
+	case msg.Stream == c.STREAM_ALL_MINI_TICKERS:
+		return decodeEvent[entity.AllMiniTickersEvent](msg)
+	case kind == "aggTrade":
+		return decodeEvent[entity.AggTradeEvent](msg)
+	case kind == "trade":
+		return decodeEvent[entity.TradeEvent](msg)
+	case strings.HasPrefix(kind, "kline_"):
+		return decodeEvent[entity.KlineEvent](msg)
+	case kind == "bookTicker":
+		return decodeEvent[entity.BookTickerEvent](msg)
+	case kind == "depth" || kind == "depth@100ms":
+		return decodeEvent[entity.DepthUpdate](msg)
+	case strings.HasPrefix(kind, "depth"):
+		e := entity.PartialDepthEvent{Symbol: strings.ToUpper(symbol)}
+		err := json.Unmarshal(msg.Data, &e)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding %s event: %w", msg.Stream, err)
+		}
+		return e, nil
+	case kind == "avgPrice":
+		return decodeEvent[entity.AvgPriceEvent](msg)
+	case kind == "ticker":
+		return decodeEvent[entity.TickerEvent](msg)
+	case kind == "miniTicker":
+		return decodeEvent[entity.MiniTickerEvent](msg)
+	}
+	return nil, fmt.Errorf("unknown stream %s", msg.Stream)
+}
+
+func decodeEvent[T entity.MarketEvent](msg StreamMessage) (entity.MarketEvent, error) {
+	var e T
+	err := json.Unmarshal(msg.Data, &e)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s event: %w", msg.Stream, err)
+	}
+	return e, nil
+}
+
+// StartEvents connects like Start, and delivers the events decoded. Events that can't be decoded are logged and skipped.
+// The channel is closed when the context is cancelled.
+func (m *StreamManager) StartEvents(ctx context.Context) <-chan entity.MarketEvent {
+	messages := m.Start(ctx)
+	events := make(chan entity.MarketEvent, c.WS_BUFFER_SIZE)
+	go func() {
+		defer close(events)
+		for msg := range messages {
+			e, err := DecodeMarketEvent(msg)
+			if err != nil {
+				slog.Error("error decoding market event", "error", err)
+				continue
+			}
+			// Events of the messages left in the buffer are dropped once the context is cancelled
+			select {
+			case events <- e:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return events
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+)
+
+func TestDecodeMarketEvent(t *testing.T) {
+	tests := []struct {
+		stream string
+		data   string
+		want   entity.MarketEvent
+	}{
+		{
+			AggTradeStream("BNBBTC"),
+			`{"e":"aggTrade","E":123456789,"s":"BNBBTC","a":12345,"p":"0.001","q":"100","f":100,"l":105,"T":123456785,"m":true,"M":true}`,
+			entity.AggTradeEvent{Event: c.EVENT_AGG_TRADE, Time: 123456789, Symbol: "BNBBTC", AggTradeId: 12345, Price: "0.001", Qty: "100", FirstTradeId: 100, LastTradeId: 105, TradeTime: 123456785, IsBuyerMaker: true, IsBestMatch: true},
+		},
+		{
+			TradeStream("BNBBTC"),
+			`{"e":"trade","E":123456789,"s":"BNBBTC","t":12345,"p":"0.001","q":"100","T":123456785,"m":true,"M":true}`,
+			entity.TradeEvent{Event: c.EVENT_TRADE, Time: 123456789, Symbol: "BNBBTC", TradeId: 12345, Price: "0.001", Qty: "100", TradeTime: 123456785, IsBuyerMaker: true, IsBestMatch: true},
+		},
+		{
+			KlineStream("BNBBTC", "1m"),
+			`{"e":"kline","E":123456789,"s":"BNBBTC","k":{"t":123400000,"T":123460000,"s":"BNBBTC","i":"1m","f":100,"L":200,"o":"0.0010","c":"0.0020","h":"0.0025","l":"0.0015","v":"1000","n":100,"x":false,"q":"1.0000","V":"500","Q":"0.500","B":"123456"}}`,
+			entity.KlineEvent{Event: c.EVENT_KLINE, Time: 123456789, Symbol: "BNBBTC", Kline: entity.StreamKline{
+				OpenTime: 123400000, CloseTime: 123460000, Symbol: "BNBBTC", Interval: "1m", FirstTradeId: 100, LastTradeId: 200,
+				Open: "0.0010", Close: "0.0020", High: "0.0025", Low: "0.0015", Volume: "1000", NumberOfTrades: 100,
+				QuoteAssetVolume: "1.0000", TakerBuyBaseAssetVolume: "500", TakerBuyQuoteAssetVolume: "0.500",
+			}},
+		},
+		{
+			BookTickerStream("BNBUSDT"),
+			`{"u":400900217,"s":"BNBUSDT","b":"25.35190000","B":"31.21000000","a":"25.36520000","A":"40.66000000"}`,
+			entity.BookTickerEvent{UpdateId: 400900217, Symbol: "BNBUSDT", BidPrice: "25.35190000", BidQty: "31.21000000", AskPrice: "25.36520000", AskQty: "40.66000000"},
+		},
+		{
+			PartialDepthStream("BNBBTC", 5, true),
+			`{"lastUpdateId":160,"bids":[["0.0024","10"]],"asks":[["0.0026","100"]]}`,
+			entity.PartialDepthEvent{Symbol: "BNBBTC", DepthResp: entity.DepthResp{LastUpdateId: 160, Bids: [][2]string{{"0.0024", "10"}}, Asks: [][2]string{{"0.0026", "100"}}}},
+		},
+		{
+			DepthStream("BNBBTC", false),
+			`{"e":"depthUpdate","E":123456789,"s":"BNBBTC","U":157,"u":160,"b":[["0.0024","10"]],"a":[["0.0026","100"]]}`,
+			entity.DepthUpdate{Event: c.EVENT_DEPTH_UPDATE, Time: 123456789, Symbol: "BNBBTC", FirstUpdateId: 157, FinalUpdateId: 160, Bids: [][2]string{{"0.0024", "10"}}, Asks: [][2]string{{"0.0026", "100"}}},
+		},
+		{
+			AvgPriceStream("LTCBTC"),
+			`{"e":"avgPrice","E":1693907033000,"s":"LTCBTC","i":"5m","w":"0.25141345","T":1693907032213}`,
+			entity.AvgPriceEvent{Event: c.EVENT_AVG_PRICE, Time: 1693907033000, Symbol: "LTCBTC", Interval: "5m", Price: "0.25141345", LastTradeTime: 1693907032213},
+		},
+		{
+			MiniTickerStream("BNBBTC"),
+			`{"e":"24hrMiniTicker","E":123456789,"s":"BNBBTC","c":"0.0025","o":"0.0010","h":"0.0025","l":"0.0010","v":"10000","q":"18"}`,
+			entity.MiniTickerEvent{Event: c.EVENT_MINI_TICKER, Time: 123456789, Symbol: "BNBBTC", Close: "0.0025", Open: "0.0010", High: "0.0025", Low: "0.0010", Volume: "10000", QuoteVolume: "18"},
+		},
+		{
+			c.STREAM_ALL_MINI_TICKERS,
+			`[{"e":"24hrMiniTicker","E":123456789,"s":"BNBBTC","c":"0.0025"},{"e":"24hrMiniTicker","E":123456789,"s":"ETHBTC","c":"0.05"}]`,
+			entity.AllMiniTickersEvent{
+				{Event: c.EVENT_MINI_TICKER, Time: 123456789, Symbol: "BNBBTC", Close: "0.0025"},
+				{Event: c.EVENT_MINI_TICKER, Time: 123456789, Symbol: "ETHBTC", Close: "0.05"},
+			},
+		},
+		{
+			c.STREAM_ALL_TICKERS,
+			`[{"e":"24hrTicker","E":123456789,"s":"BNBBTC","p":"0.0015","c":"0.0025","n":18151}]`,
+			entity.AllTickersEvent{{Event: c.EVENT_TICKER, Time: 123456789, Symbol: "BNBBTC", PriceChange: "0.0015", LastPrice: "0.0025", NumberOfTrades: 18151}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stream, func(t *testing.T) {
+			got, err := DecodeMarketEvent(StreamMessage{Stream: tt.stream, Data: []byte(tt.data)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	_, err := DecodeMarketEvent(StreamMessage{Stream: "bnbbtc@unknown", Data: []byte(`{}`)})
+	if err == nil {
+		t.Error("unknown stream: got no error")
+	}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+)
+
+func TestMyTrades(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	// 1500 trades on the first day, more than a page, and 10 on the second
+	var all []entity.Trade
+	for i := 1; i <= 1510; i++ {
+		at := day.Add(time.Duration(i) * time.Second)
+		if i > 1500 {
+			at = day.Add(24*time.Hour + time.Duration(i)*time.Second)
+		}
+		all = append(all, entity.Trade{Symbol: "BTCFDUSD", Id: int64(i), Time: uint64(at.UnixMilli())})
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == c.PATH_TIME {
+			fmt.Fprintf(w, `{"serverTime":%d}`, time.Now().UnixMilli())
+			return
+		}
+		requests++
+		q := r.URL.Query()
+		if q.Get("fromId") != "" && q.Get("startTime") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":-1128,"msg":"Combination of optional parameters invalid."}`)
+			return
+		}
+		fromId, _ := strconv.ParseInt(q.Get("fromId"), 10, 64)
+		start, _ := strconv.ParseUint(q.Get("startTime"), 10, 64)
+		end, _ := strconv.ParseUint(q.Get("endTime"), 10, 64)
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		if end > 0 && end-start >= uint64(c.MAX_QUERY_WINDOW.Milliseconds()) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":-1127,"msg":"More than 24 hours between startTime and endTime."}`)
+			return
+		}
+		page := []entity.Trade{}
+		for _, tr := range all {
+			if tr.Id >= fromId && tr.Time >= start && (end == 0 || tr.Time <= end) && len(page) < limit {
+				page = append(page, tr)
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+	cl := NewClient("test", nil, "apikey", secretKey, server.URL, "")
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		fromId   int64
+		want     int // Trades
+		requests int
+		wantErr  bool
+	}{
+		// A full page on the first day is continued by trade id, up to the end of the day
+		{"period", day, day.Add(72 * time.Hour), 0, 1510, 4, false},
+		{"second day", day.Add(24 * time.Hour), day.Add(48 * time.Hour), 0, 10, 1, false},
+		{"from id", time.Time{}, time.Time{}, 1, 1510, 2, false},
+		{"from id, last page", time.Time{}, time.Time{}, 1001, 510, 1, false},
+		{"up to now", time.Now().Add(-time.Hour), time.Time{}, 0, 0, 1, false},
+		{"no start", time.Time{}, day, 0, 0, 0, true},
+		{"end before start", day.Add(time.Hour), day, 0, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			trades, err := cl.MyTrades("BTCFDUSD", tt.from, tt.to, tt.fromId)
+			if tt.wantErr {
+				if err == nil || requests != 0 {
+					t.Fatalf("got %d trades in %d requests, want an error", len(trades), requests)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(trades) != tt.want || requests != tt.requests {
+				t.Fatalf("got %d trades in %d requests, want %d in %d", len(trades), requests, tt.want, tt.requests)
+			}
+			for i := 1; i < len(trades); i++ {
+				if trades[i].Id != trades[i-1].Id+1 {
+					t.Fatalf("trade %d follows %d", trades[i].Id, trades[i-1].Id)
+				}
+			}
+		})
+	}
+}
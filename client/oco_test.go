@@ -0,0 +1,217 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+	"github.com/michelemendel/binance/fakebinance"
+)
+
+// newFakeBinanceOCO returns a fake server on which ETHFDUSD allows neither OCO nor cancel-replace.
+func newFakeBinanceOCO(t *testing.T) (*fakebinance.Server, *Client) {
+	server, cl := newFakeBinance(t)
+	info, err := cl.ExchangeInfo("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range info.Symbols {
+		if info.Symbols[i].Symbol == "ETHFDUSD" {
+			info.Symbols[i].OcoAllowed = false
+			info.Symbols[i].CancelReplaceAllowed = false
+		}
+	}
+	body, _ := json.Marshal(info)
+	server.Script(http.MethodGet, c.PATH_EXCHANGE_INFO, fakebinance.Response{Body: string(body)})
+	return server, cl
+}
+
+// checkParams compares the parameters sent, leaving out those of signed requests.
+func checkParams(t *testing.T, got url.Values, want map[string]string) {
+	t.Helper()
+	if !got.Has("timestamp") || !got.Has("signature") {
+		t.Errorf("params: got %v, want a signed request", got)
+	}
+	for _, k := range []string{"timestamp", "recvWindow", "signature"} {
+		got.Del(k)
+	}
+	if len(got) != len(want) {
+		t.Errorf("params: got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got.Get(k) != v {
+			t.Errorf("param %s: got %q, want %q", k, got.Get(k), v)
+		}
+	}
+}
+
+func TestPlaceOCO(t *testing.T) {
+	server, cl := newFakeBinanceOCO(t)
+	listBody := `{"orderListId":7,"contingencyType":"OCO","listStatusType":"EXEC_STARTED","listOrderStatus":"EXECUTING","listClientOrderId":"list","symbol":"BTCFDUSD",` +
+		`"orders":[{"symbol":"BTCFDUSD","orderId":11,"clientOrderId":"a"},{"symbol":"BTCFDUSD","orderId":12,"clientOrderId":"b"}]}`
+
+	tests := []struct {
+		name    string
+		req     entity.OCORequest
+		want    map[string]string // Params sent, nil if the order isn't sent
+		wantErr string
+	}{
+		{
+			name: "sell",
+			req: entity.OCORequest{Symbol: "BTCFDUSD", Side: c.SIDE_SELL, Quantity: 0.0012345, Price: 45000.123, StopPrice: 35000.001,
+				StopLimitPrice: 34900, StopLimitTimeInForce: c.TIME_IN_FORCE_GTC},
+			want: map[string]string{"symbol": "BTCFDUSD", "side": c.SIDE_SELL, "quantity": "0.00123", "price": "45000.12", "stopPrice": "35000",
+				"stopLimitPrice": "34900", "stopLimitTimeInForce": c.TIME_IN_FORCE_GTC, "newOrderRespType": c.ORDER_RESP_TYPE_FULL},
+		},
+		{
+			name: "buy",
+			req: entity.OCORequest{Symbol: "BTCFDUSD", Side: c.SIDE_BUY, Quantity: 0.001, Price: 35000, StopPrice: 45000,
+				StopLimitPrice: 45100, StopLimitTimeInForce: c.TIME_IN_FORCE_GTC, ListClientOrderId: "list"},
+			want: map[string]string{"symbol": "BTCFDUSD", "side": c.SIDE_BUY, "quantity": "0.001", "price": "35000", "stopPrice": "45000",
+				"stopLimitPrice": "45100", "stopLimitTimeInForce": c.TIME_IN_FORCE_GTC, "listClientOrderId": "list", "newOrderRespType": c.ORDER_RESP_TYPE_FULL},
+		},
+		{
+			name:    "not allowed",
+			req:     entity.OCORequest{Symbol: "ETHFDUSD", Side: c.SIDE_SELL, Quantity: 0.01, Price: 2500, StopPrice: 1500},
+			wantErr: "OCO orders are not allowed on ETHFDUSD",
+		},
+		{
+			name:    "stop loss not allowed on the symbol",
+			req:     entity.OCORequest{Symbol: "BTCFDUSD", Side: c.SIDE_SELL, Quantity: 0.001, Price: 45000, StopPrice: 35000},
+			wantErr: "ORDER_TYPES",
+		},
+		{
+			name:    "sell price below stop price",
+			req:     entity.OCORequest{Symbol: "BTCFDUSD", Side: c.SIDE_SELL, Quantity: 0.001, Price: 35000, StopPrice: 45000},
+			wantErr: "price must be above stopPrice",
+		},
+		{
+			name:    "buy price above stop price",
+			req:     entity.OCORequest{Symbol: "BTCFDUSD", Side: c.SIDE_BUY, Quantity: 0.001, Price: 45000, StopPrice: 35000},
+			wantErr: "price must be below stopPrice",
+		},
+		{
+			name:    "stop limit without time in force",
+			req:     entity.OCORequest{Symbol: "BTCFDUSD", Side: c.SIDE_SELL, Quantity: 0.001, Price: 45000, StopPrice: 35000, StopLimitPrice: 34900},
+			wantErr: "OCO STOP_LOSS_LIMIT leg",
+		},
+		{
+			name: "below min notional",
+			req: entity.OCORequest{Symbol: "BTCFDUSD", Side: c.SIDE_SELL, Quantity: 0.0001, Price: 45000, StopPrice: 35000,
+				StopLimitPrice: 34900, StopLimitTimeInForce: c.TIME_IN_FORCE_GTC},
+			wantErr: "NOTIONAL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := len(server.Requests(http.MethodPost, c.PATH_ORDER_OCO))
+			if tt.want != nil {
+				server.Script(http.MethodPost, c.PATH_ORDER_OCO, fakebinance.Response{Body: listBody})
+			}
+			list, err := cl.PlaceOCO(tt.req)
+			requests := server.Requests(http.MethodPost, c.PATH_ORDER_OCO)[sent:]
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error %v, want %q", err, tt.wantErr)
+				}
+				if len(requests) != 0 {
+					t.Errorf("requests: got %d, want 0", len(requests))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if list.OrderListId != 7 || len(list.Orders) != 2 {
+				t.Errorf("order list: got %+v", list)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("requests: got %d, want 1", len(requests))
+			}
+			checkParams(t, requests[0].Params, tt.want)
+		})
+	}
+}
+
+func TestCancelReplace(t *testing.T) {
+	server, cl := newFakeBinanceOCO(t)
+	resultBody := `{"cancelResult":"SUCCESS","newOrderResult":"SUCCESS",` +
+		`"cancelResponse":{"symbol":"BTCFDUSD","orderId":1,"status":"CANCELED"},` +
+		`"newOrderResponse":{"symbol":"BTCFDUSD","orderId":2,"status":"NEW","fills":[]}}`
+
+	tests := []struct {
+		name          string
+		cancelOrderId int64
+		cancelId      string
+		req           entity.OrderRequest
+		mode          string
+		want          map[string]string // Params sent, nil if the order isn't sent
+		wantErr       string
+	}{
+		{
+			name: "by order id", cancelOrderId: 1, mode: c.CANCEL_REPLACE_STOP_ON_FAILURE,
+			req: entity.OrderRequest{Symbol: "BTCFDUSD", Side: c.SIDE_BUY, Type: c.ORDER_TYPE_LIMIT, TimeInForce: c.TIME_IN_FORCE_GTC, Quantity: 0.0012345, Price: 39000.456},
+			want: map[string]string{"symbol": "BTCFDUSD", "side": c.SIDE_BUY, "type": c.ORDER_TYPE_LIMIT, "timeInForce": c.TIME_IN_FORCE_GTC,
+				"quantity": "0.00123", "price": "39000.46", "cancelReplaceMode": c.CANCEL_REPLACE_STOP_ON_FAILURE, "cancelOrderId": "1", "newOrderRespType": c.ORDER_RESP_TYPE_FULL},
+		},
+		{
+			name: "by client order id, market", cancelId: "a", mode: c.CANCEL_REPLACE_ALLOW_FAILURE,
+			req: entity.OrderRequest{Symbol: "BTCFDUSD", Side: c.SIDE_SELL, Quantity: 0.001, NewClientOrderId: "b"},
+			want: map[string]string{"symbol": "BTCFDUSD", "side": c.SIDE_SELL, "type": c.ORDER_TYPE_MARKET, "quantity": "0.001",
+				"newClientOrderId": "b", "cancelReplaceMode": c.CANCEL_REPLACE_ALLOW_FAILURE, "cancelOrigClientOrderId": "a", "newOrderRespType": c.ORDER_RESP_TYPE_FULL},
+		},
+		{
+			name: "not allowed", cancelOrderId: 1, mode: c.CANCEL_REPLACE_STOP_ON_FAILURE,
+			req:     entity.OrderRequest{Symbol: "ETHFDUSD", Side: c.SIDE_BUY, Quantity: 0.01},
+			wantErr: "cancel-replace is not allowed on ETHFDUSD",
+		},
+		{
+			name: "no order to cancel", mode: c.CANCEL_REPLACE_STOP_ON_FAILURE,
+			req:     entity.OrderRequest{Symbol: "BTCFDUSD", Side: c.SIDE_BUY, Quantity: 0.001},
+			wantErr: "either cancelOrderId or cancelOrigClientOrderId must be given",
+		},
+		{
+			name: "unknown mode", cancelOrderId: 1, mode: "STOP",
+			req:     entity.OrderRequest{Symbol: "BTCFDUSD", Side: c.SIDE_BUY, Quantity: 0.001},
+			wantErr: "unknown cancel-replace mode",
+		},
+		{
+			name: "dry run", cancelOrderId: 1, mode: c.CANCEL_REPLACE_STOP_ON_FAILURE,
+			req:     entity.OrderRequest{Symbol: "BTCFDUSD", Side: c.SIDE_BUY, Quantity: 0.001, DryRun: true},
+			wantErr: ErrDryRunNotSupported.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := len(server.Requests(http.MethodPost, c.PATH_CANCEL_REPLACE))
+			if tt.want != nil {
+				server.Script(http.MethodPost, c.PATH_CANCEL_REPLACE, fakebinance.Response{Body: resultBody})
+			}
+			result, err := cl.CancelReplace(tt.cancelOrderId, tt.cancelId, tt.req, tt.mode)
+			requests := server.Requests(http.MethodPost, c.PATH_CANCEL_REPLACE)[sent:]
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error %v, want %q", err, tt.wantErr)
+				}
+				if len(requests) != 0 {
+					t.Errorf("requests: got %d, want 0", len(requests))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.CancelResponse == nil || result.CancelResponse.OrderId != 1 || result.NewOrderResponse == nil || result.NewOrderResponse.OrderId != 2 {
+				t.Errorf("result: got %+v", result)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("requests: got %d, want 1", len(requests))
+			}
+			checkParams(t, requests[0].Params, tt.want)
+		})
+	}
+}
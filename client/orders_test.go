@@ -0,0 +1,155 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+)
+
+func TestGetCancelOrder(t *testing.T) {
+	server, cl := newFakeBinance(t)
+	placed, err := cl.Buy(entity.OrderRequest{Symbol: "BTCFDUSD", Type: c.ORDER_TYPE_LIMIT, TimeInForce: c.TIME_IN_FORCE_GTC, Quantity: 0.001, Price: 30000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byId, err := cl.GetOrder("BTCFDUSD", placed.OrderId, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byClientId, err := cl.GetOrder("BTCFDUSD", 0, placed.ClientOrderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byId.OrderId != placed.OrderId || byClientId.OrderId != placed.OrderId || byId.Status != "NEW" || byId.Price != "30000.00000000" {
+		t.Errorf("got %+v and %+v", byId, byClientId)
+	}
+	if _, err := cl.GetOrder("BTCFDUSD", 0, ""); err == nil {
+		t.Error("no order id: got no error")
+	}
+	if n := len(server.Requests(http.MethodGet, c.PATH_ORDER)); n != 2 {
+		t.Errorf("requests: got %d, want 2", n)
+	}
+	if _, err := cl.GetOrder("BTCFDUSD", 999, ""); !IsAPIError(err, c.ERROR_CODE_NO_SUCH_ORDER) {
+		t.Errorf("unknown order: got %v, want code %d", err, c.ERROR_CODE_NO_SUCH_ORDER)
+	}
+
+	canceled, err := cl.CancelOrder("BTCFDUSD", 0, placed.ClientOrderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canceled.OrderId != placed.OrderId || canceled.Status != "CANCELED" {
+		t.Errorf("canceled: got %+v", canceled)
+	}
+	if _, err := cl.CancelOrder("BTCFDUSD", placed.OrderId, ""); !IsAPIError(err, c.ERROR_CODE_CANCEL_REJECTED) {
+		t.Errorf("canceled twice: got %v, want code %d", err, c.ERROR_CODE_CANCEL_REJECTED)
+	}
+	if _, err := cl.CancelOrder("BTCFDUSD", 0, ""); err == nil {
+		t.Error("no order id: got no error")
+	}
+}
+
+func TestOpenOrders(t *testing.T) {
+	server, cl := newFakeBinance(t)
+	for _, req := range []entity.OrderRequest{
+		{Symbol: "BTCFDUSD", Type: c.ORDER_TYPE_LIMIT, TimeInForce: c.TIME_IN_FORCE_GTC, Quantity: 0.001, Price: 30000},
+		{Symbol: "BTCFDUSD", Type: c.ORDER_TYPE_LIMIT, TimeInForce: c.TIME_IN_FORCE_GTC, Quantity: 0.001, Price: 35000},
+		{Symbol: "ETHFDUSD", Type: c.ORDER_TYPE_LIMIT, TimeInForce: c.TIME_IN_FORCE_GTC, Quantity: 0.01, Price: 1500},
+	} {
+		if _, err := cl.Buy(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		symbol string
+		want   int
+	}{{"BTCFDUSD", 2}, {"ETHFDUSD", 1}, {"", 3}} {
+		orders, err := cl.OpenOrders(tt.symbol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(orders) != tt.want {
+			t.Errorf("open orders of %q: got %d, want %d", tt.symbol, len(orders), tt.want)
+		}
+	}
+	requests := server.Requests(http.MethodGet, c.PATH_OPEN_ORDERS)
+	if requests[2].Params.Has("symbol") {
+		t.Errorf("all symbols: got params %v", requests[2].Params)
+	}
+
+	canceled, err := cl.CancelAllOpenOrders("BTCFDUSD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(canceled) != 2 || canceled[0].Status != "CANCELED" || canceled[1].Status != "CANCELED" {
+		t.Errorf("canceled: got %+v", canceled)
+	}
+	orders, err := cl.OpenOrders("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 || orders[0].Symbol != "ETHFDUSD" {
+		t.Errorf("open orders after cancel: got %+v", orders)
+	}
+}
+
+func TestAllOrders(t *testing.T) {
+	server, cl := newFakeBinance(t)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	add := func(at time.Time, n int) {
+		for i := 0; i < n; i++ {
+			server.AddOrder(entity.Order{Symbol: "BTCFDUSD", Status: "FILLED", Type: c.ORDER_TYPE_MARKET, Side: c.SIDE_BUY, Time: uint64(at.UnixMilli())})
+		}
+	}
+	// More than two pages created in the same millisecond on the first day, a few on the second and fourth, and one recent
+	add(day.Add(time.Hour), 2500)
+	add(day.Add(2*time.Hour), 10)
+	add(day.Add(30*time.Hour), 5)
+	add(day.Add(80*time.Hour), 3)
+	add(time.Now().Add(-time.Hour), 1)
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     int // Orders
+		requests int
+		wantErr  bool
+	}{
+		// The first day has a full page by time, continued by order id up to the end of the day
+		{name: "period", from: day, to: day.Add(96 * time.Hour), want: 2518, requests: 4 + 2},
+		{name: "second day", from: day.Add(24 * time.Hour), to: day.Add(48 * time.Hour), want: 5, requests: 1},
+		{name: "up to now", from: time.Now().Add(-2 * time.Hour), want: 1, requests: 1},
+		{name: "same millisecond", from: day.Add(time.Hour), to: day.Add(time.Hour + time.Millisecond), want: 2500, requests: 3},
+		{name: "no start", to: day, wantErr: true},
+		{name: "end before start", from: day.Add(time.Hour), to: day, wantErr: true},
+		{name: "empty period", from: day, to: day, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(server.Requests(http.MethodGet, c.PATH_ALL_ORDERS))
+			orders, err := cl.AllOrders("BTCFDUSD", tt.from, tt.to)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %d orders, want an error", len(orders))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			requests := len(server.Requests(http.MethodGet, c.PATH_ALL_ORDERS)) - before
+			if len(orders) != tt.want || requests != tt.requests {
+				t.Fatalf("got %d orders in %d requests, want %d in %d", len(orders), requests, tt.want, tt.requests)
+			}
+			for i := 1; i < len(orders); i++ {
+				if orders[i].OrderId <= orders[i-1].OrderId {
+					t.Fatalf("order %d follows %d", orders[i].OrderId, orders[i-1].OrderId)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+)
+
+// RateLimiter keeps requests within the REQUEST_WEIGHT and ORDERS limits.
+// Weights are reserved before a request is made, and corrected with the usage Binance reports in the response headers.
+// https://binance-docs.github.io/apidocs/spot/en/#limits
+type RateLimiter struct {
+	mu         sync.Mutex
+	weight1m   window
+	orders10s  window
+	orders1d   window
+	retryAfter time.Time
+	banned     bool
+}
+
+// window is a fixed window, like Binance's, that resets at each whole interval.
+type window struct {
+	interval time.Duration
+	limit    int
+	used     int
+	resetAt  time.Time
+}
+
+func (w *window) roll(now time.Time) {
+	if !now.Before(w.resetAt) {
+		w.used = 0
+		w.resetAt = now.Truncate(w.interval).Add(w.interval)
+	}
+}
+
+func (w *window) exceeded(n int) bool {
+	return w.limit > 0 && w.used+n > w.limit
+}
+
+// Request weights of the endpoints, keyed by method and path. Unknown endpoints weigh 1.
+// https://binance-docs.github.io/apidocs/spot/en/#general-endpoints
+var endpointWeights = map[string]int{
+	"GET " + c.PATH_EXCHANGE_INFO:       20,
+	"GET " + c.PATH_TICKER_PRICE:        2,
+	"GET " + c.PATH_KLINES:              2,
+	"GET " + c.PATH_DEPTH:               50, // For limit 1000; 5 up to 100, 250 for 5000
+	"GET " + c.PATH_ORDER:               4,
+	"POST " + c.PATH_ORDER_TEST:         20, // With computeCommissionRates
+	"GET " + c.PATH_OPEN_ORDERS:         6,  // 80 without a symbol
+	"GET " + c.PATH_ALL_ORDERS:          20,
+	"GET " + c.PATH_ACCOUNT:             20,
+	"GET " + c.PATH_MY_TRADES:           20,
+	"POST " + c.PATH_USER_DATA_STREAM:   2,
+	"PUT " + c.PATH_USER_DATA_STREAM:    2,
+	"DELETE " + c.PATH_USER_DATA_STREAM: 2,
+}
+
+// Endpoints that count towards the ORDERS limits
+var orderEndpoints = map[string]bool{
+	"POST " + c.PATH_ORDER:          true,
+	"POST " + c.PATH_ORDER_OCO:      true,
+	"POST " + c.PATH_CANCEL_REPLACE: true,
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		weight1m:  window{interval: time.Minute, limit: c.DEFAULT_WEIGHT_LIMIT_1M},
+		orders10s: window{interval: 10 * time.Second, limit: c.DEFAULT_ORDER_LIMIT_10S},
+		orders1d:  window{interval: 24 * time.Hour, limit: c.DEFAULT_ORDER_LIMIT_1D},
+	}
+}
+
+// SetLimits sets the limits from the rateLimits in exchangeInfo.
+func (r *RateLimiter) SetLimits(limits []entity.RateLimit) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range limits {
+		switch {
+		case l.RateLimitType == c.RATE_LIMIT_REQUEST_WEIGHT && l.Interval == "MINUTE" && l.IntervalNum == 1:
+			r.weight1m.limit = l.Limit
+		case l.RateLimitType == c.RATE_LIMIT_ORDERS && l.Interval == "SECOND" && l.IntervalNum == 10:
+			r.orders10s.limit = l.Limit
+		case l.RateLimitType == c.RATE_LIMIT_ORDERS && l.Interval == "DAY" && l.IntervalNum == 1:
+			r.orders1d.limit = l.Limit
+		}
+	}
+}
+
+// Wait blocks until the request can be made without exceeding a limit, and reserves its weight.
+// It returns an error instead of waiting when the wait would be longer than MAX_RATE_LIMIT_WAIT,
+// e.g. when the IP is banned or the daily order limit is reached.
+func (r *RateLimiter) Wait(method, path string) error {
+	key := method + " " + path
+	weight, ok := endpointWeights[key]
+	if !ok {
+		weight = 1
+	}
+	isOrder := orderEndpoints[key]
+
+	for {
+		r.mu.Lock()
+		now := time.Now()
+		r.weight1m.roll(now)
+		r.orders10s.roll(now)
+		r.orders1d.roll(now)
+
+		var wait time.Duration
+		reason := ""
+		switch {
+		case now.Before(r.retryAfter):
+			wait, reason = r.retryAfter.Sub(now), "retry after"
+			if r.banned {
+				reason = "IP banned"
+			}
+		case r.weight1m.exceeded(weight):
+			wait, reason = r.weight1m.resetAt.Sub(now), "request weight"
+		case isOrder && r.orders10s.exceeded(1):
+			wait, reason = r.orders10s.resetAt.Sub(now), "orders per 10s"
+		case isOrder && r.orders1d.exceeded(1):
+			wait, reason = r.orders1d.resetAt.Sub(now), "orders per day"
+		}
+
+		if wait <= 0 {
+			r.weight1m.used += weight
+			if isOrder {
+				r.orders10s.used++
+				r.orders1d.used++
+			}
+			r.mu.Unlock()
+			return nil
+		}
+		r.mu.Unlock()
+
+		if wait > c.MAX_RATE_LIMIT_WAIT {
+			return fmt.Errorf("rate limit (%s) reached for %s, retry in %v", reason, key, wait.Round(time.Second))
+		}
+		slog.Warn("rate limit reached, waiting", "limit", reason, "endpoint", key, "wait", wait)
+		time.Sleep(wait)
+	}
+}
+
+// Update records the usage reported in the response headers, and the Retry-After of 429 and 418 responses.
+func (r *RateLimiter) Update(header http.Header, statusCode int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, u := range []struct {
+		w      *window
+		header string
+	}{
+		{&r.weight1m, c.HEADER_USED_WEIGHT_1M},
+		{&r.orders10s, c.HEADER_ORDER_COUNT_10S},
+		{&r.orders1d, c.HEADER_ORDER_COUNT_1D},
+	} {
+		used, err := strconv.Atoi(header.Get(u.header))
+		if err != nil {
+			continue
+		}
+		u.w.roll(now)
+		// Requests still in flight are reserved but not yet counted by Binance
+		if used > u.w.used {
+			u.w.used = used
+		}
+	}
+
+	if statusCode == http.StatusTooManyRequests || statusCode == http.StatusTeapot {
+		seconds, err := strconv.Atoi(header.Get(c.HEADER_RETRY_AFTER))
+		if err != nil || seconds <= 0 {
+			seconds = 1
+		}
+		r.retryAfter = now.Add(time.Duration(seconds) * time.Second)
+		r.banned = statusCode == http.StatusTeapot
+		slog.Error("rate limit exceeded", "status", statusCode, "retryAfter", r.retryAfter)
+	}
+}
+
+// Usage returns the current usage of the rate limits, e.g. for display.
+func (r *RateLimiter) Usage() entity.RateLimitUsage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.weight1m.roll(now)
+	r.orders10s.roll(now)
+	r.orders1d.roll(now)
+	return entity.RateLimitUsage{
+		UsedWeight1m:  r.weight1m.used,
+		WeightLimit1m: r.weight1m.limit,
+		OrderCount10s: r.orders10s.used,
+		OrderLimit10s: r.orders10s.limit,
+		OrderCount1d:  r.orders1d.used,
+		OrderLimit1d:  r.orders1d.limit,
+		RetryAfter:    r.retryAfter,
+		Banned:        r.banned && now.Before(r.retryAfter),
+	}
+}
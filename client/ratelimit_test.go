@@ -0,0 +1,215 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	tests := []struct {
+		name         string
+		setup        func(r *RateLimiter)
+		method, path string
+		wantErr      string
+		minWait      time.Duration
+		wantUsage    entity.RateLimitUsage
+	}{
+		{
+			name:   "weight",
+			method: http.MethodGet, path: c.PATH_EXCHANGE_INFO,
+			wantUsage: entity.RateLimitUsage{UsedWeight1m: 20},
+		},
+		{
+			name:   "unknown endpoint",
+			method: http.MethodGet, path: "/api/v3/unknown",
+			wantUsage: entity.RateLimitUsage{UsedWeight1m: 1},
+		},
+		{
+			name:   "order",
+			method: http.MethodPost, path: c.PATH_ORDER,
+			wantUsage: entity.RateLimitUsage{UsedWeight1m: 1, OrderCount10s: 1, OrderCount1d: 1},
+		},
+		{
+			name: "waits for the weight window",
+			setup: func(r *RateLimiter) {
+				r.weight1m = window{interval: time.Minute, limit: 20, used: 20, resetAt: time.Now().Add(50 * time.Millisecond)}
+			},
+			method: http.MethodGet, path: c.PATH_EXCHANGE_INFO,
+			minWait:   50 * time.Millisecond,
+			wantUsage: entity.RateLimitUsage{UsedWeight1m: 20},
+		},
+		{
+			name: "waits for retry after",
+			setup: func(r *RateLimiter) {
+				r.retryAfter = time.Now().Add(50 * time.Millisecond)
+			},
+			method: http.MethodGet, path: c.PATH_TICKER_PRICE,
+			minWait:   50 * time.Millisecond,
+			wantUsage: entity.RateLimitUsage{UsedWeight1m: 2},
+		},
+		{
+			name: "daily order limit",
+			setup: func(r *RateLimiter) {
+				r.orders1d = window{interval: 24 * time.Hour, limit: 1, used: 1, resetAt: time.Now().Add(time.Hour)}
+			},
+			method: http.MethodPost, path: c.PATH_ORDER,
+			wantErr:   "orders per day",
+			wantUsage: entity.RateLimitUsage{OrderCount1d: 1},
+		},
+		{
+			name: "orders only count for order endpoints",
+			setup: func(r *RateLimiter) {
+				r.orders1d = window{interval: 24 * time.Hour, limit: 1, used: 1, resetAt: time.Now().Add(time.Hour)}
+			},
+			method: http.MethodGet, path: c.PATH_ORDER,
+			wantUsage: entity.RateLimitUsage{UsedWeight1m: 4, OrderCount1d: 1},
+		},
+		{
+			name: "banned",
+			setup: func(r *RateLimiter) {
+				r.retryAfter, r.banned = time.Now().Add(time.Hour), true
+			},
+			method: http.MethodGet, path: c.PATH_TIME,
+			wantErr: "IP banned",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRateLimiter()
+			if tt.setup != nil {
+				tt.setup(r)
+			}
+			start := time.Now()
+			err := r.Wait(tt.method, tt.path)
+			if tt.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			if waited := time.Since(start); waited < tt.minWait {
+				t.Errorf("waited %v, want at least %v", waited, tt.minWait)
+			}
+			u := r.Usage()
+			if u.UsedWeight1m != tt.wantUsage.UsedWeight1m || u.OrderCount10s != tt.wantUsage.OrderCount10s || u.OrderCount1d != tt.wantUsage.OrderCount1d {
+				t.Errorf("got usage %+v, want %+v", u, tt.wantUsage)
+			}
+		})
+	}
+}
+
+func TestRateLimiterUpdate(t *testing.T) {
+	header := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+	tests := []struct {
+		name       string
+		reserved   int // Weight reserved by Wait before the response
+		header     http.Header
+		status     int
+		wantUsage  entity.RateLimitUsage
+		retryAfter time.Duration // 0 if not set
+	}{
+		{
+			name:   "used weight and order counts",
+			header: header(c.HEADER_USED_WEIGHT_1M, "120", c.HEADER_ORDER_COUNT_10S, "3", c.HEADER_ORDER_COUNT_1D, "42"),
+			status: http.StatusOK,
+			wantUsage: entity.RateLimitUsage{
+				UsedWeight1m: 120, OrderCount10s: 3, OrderCount1d: 42,
+			},
+		},
+		{
+			name:      "reserved weight of requests in flight is kept",
+			reserved:  40,
+			header:    header(c.HEADER_USED_WEIGHT_1M, "20"),
+			status:    http.StatusOK,
+			wantUsage: entity.RateLimitUsage{UsedWeight1m: 40},
+		},
+		{
+			name:      "invalid header is ignored",
+			reserved:  20,
+			header:    header(c.HEADER_USED_WEIGHT_1M, "x"),
+			status:    http.StatusOK,
+			wantUsage: entity.RateLimitUsage{UsedWeight1m: 20},
+		},
+		{
+			name:       "429",
+			header:     header(c.HEADER_USED_WEIGHT_1M, "6001", c.HEADER_RETRY_AFTER, "30"),
+			status:     http.StatusTooManyRequests,
+			wantUsage:  entity.RateLimitUsage{UsedWeight1m: 6001},
+			retryAfter: 30 * time.Second,
+		},
+		{
+			name:       "418",
+			header:     header(c.HEADER_RETRY_AFTER, "7200"),
+			status:     http.StatusTeapot,
+			wantUsage:  entity.RateLimitUsage{Banned: true},
+			retryAfter: 2 * time.Hour,
+		},
+		{
+			name:       "429 without Retry-After",
+			header:     header(),
+			status:     http.StatusTooManyRequests,
+			retryAfter: time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRateLimiter()
+			r.weight1m.roll(time.Now())
+			r.weight1m.used = tt.reserved
+			r.Update(tt.header, tt.status)
+
+			u := r.Usage()
+			if u.UsedWeight1m != tt.wantUsage.UsedWeight1m || u.OrderCount10s != tt.wantUsage.OrderCount10s ||
+				u.OrderCount1d != tt.wantUsage.OrderCount1d || u.Banned != tt.wantUsage.Banned {
+				t.Errorf("got usage %+v, want %+v", u, tt.wantUsage)
+			}
+			if tt.retryAfter == 0 {
+				if !u.RetryAfter.IsZero() {
+					t.Errorf("got retry after %v, want none", u.RetryAfter)
+				}
+				return
+			}
+			if d := time.Until(u.RetryAfter); d <= tt.retryAfter-time.Second || d > tt.retryAfter {
+				t.Errorf("got retry after in %v, want %v", d, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestRateLimiterSetLimits(t *testing.T) {
+	r := NewRateLimiter()
+	r.SetLimits([]entity.RateLimit{
+		{RateLimitType: c.RATE_LIMIT_REQUEST_WEIGHT, Interval: "MINUTE", IntervalNum: 1, Limit: 1200},
+		{RateLimitType: c.RATE_LIMIT_ORDERS, Interval: "SECOND", IntervalNum: 10, Limit: 50},
+		{RateLimitType: c.RATE_LIMIT_ORDERS, Interval: "DAY", IntervalNum: 1, Limit: 160000},
+		// Not tracked
+		{RateLimitType: "RAW_REQUESTS", Interval: "MINUTE", IntervalNum: 5, Limit: 61000},
+		{RateLimitType: c.RATE_LIMIT_REQUEST_WEIGHT, Interval: "SECOND", IntervalNum: 1, Limit: 1},
+	})
+	u := r.Usage()
+	if u.WeightLimit1m != 1200 || u.OrderLimit10s != 50 || u.OrderLimit1d != 160000 {
+		t.Errorf("got limits %+v", u)
+	}
+
+	// A 1200 weight limit is exceeded after 60 exchangeInfo requests
+	for i := 0; i < 60; i++ {
+		r.Wait(http.MethodGet, c.PATH_EXCHANGE_INFO)
+	}
+	r.mu.Lock()
+	exceeded := r.weight1m.exceeded(endpointWeights["GET "+c.PATH_EXCHANGE_INFO])
+	r.mu.Unlock()
+	if !exceeded {
+		t.Error("weight limit not exceeded")
+	}
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/michelemendel/binance/entity"
+)
+
+// SymbolRegistry caches the exchange information for all symbols.
+// It's loaded on first use and refreshed when it's older than the refresh interval.
+// If a refresh fails, the previously loaded data is used.
+type SymbolRegistry struct {
+	client          *Client
+	refreshInterval time.Duration
+
+	mu       sync.RWMutex
+	symbols  map[string]entity.SymbolInfo
+	loadedAt time.Time
+}
+
+func NewSymbolRegistry(client *Client, refreshInterval time.Duration) *SymbolRegistry {
+	return &SymbolRegistry{
+		client:          client,
+		refreshInterval: refreshInterval,
+		symbols:         map[string]entity.SymbolInfo{},
+	}
+}
+
+// Refresh fetches the exchange information for all symbols.
+func (r *SymbolRegistry) Refresh() error {
+	info, err := r.client.ExchangeInfo("")
+	if err != nil {
+		return fmt.Errorf("error loading symbols: %w", err)
+	}
+	if len(info.Symbols) == 0 {
+		return fmt.Errorf("error loading symbols: exchange info has no symbols")
+	}
+
+	symbols := make(map[string]entity.SymbolInfo, len(info.Symbols))
+	for _, s := range info.Symbols {
+		symbols[s.Symbol] = s
+	}
+
+	r.client.RateLimiter.SetLimits(info.RateLimits)
+
+	r.mu.Lock()
+	r.symbols = symbols
+	r.loadedAt = time.Now()
+	r.mu.Unlock()
+
+	slog.Info("loaded symbols", "count", len(symbols))
+	return nil
+}
+
+func (r *SymbolRegistry) ensureFresh() error {
+	r.mu.RLock()
+	loaded := !r.loadedAt.IsZero()
+	stale := time.Since(r.loadedAt) > r.refreshInterval
+	r.mu.RUnlock()
+
+	if loaded && !stale {
+		return nil
+	}
+	err := r.Refresh()
+	if err != nil && loaded {
+		slog.Error("using stale symbols", "loadedAt", r.LoadedAt(), "error", err)
+		return nil
+	}
+	return err
+}
+
+// LoadedAt returns when the symbols were last loaded, or the zero time if they never were.
+func (r *SymbolRegistry) LoadedAt() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.loadedAt
+}
+
+// Symbol returns the exchange information for a symbol, e.g. BTCFDUSD.
+func (r *SymbolRegistry) Symbol(pair string) (*entity.SymbolInfo, error) {
+	err := r.ensureFresh()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.symbols[pair]
+	if !ok {
+		return nil, fmt.Errorf("symbol %s not found in exchange info", pair)
+	}
+	return &s, nil
+}
+
+// Symbols returns all symbols, sorted by name.
+func (r *SymbolRegistry) Symbols() ([]entity.SymbolInfo, error) {
+	err := r.ensureFresh()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	symbols := make([]entity.SymbolInfo, 0, len(r.symbols))
+	for _, s := range r.symbols {
+		symbols = append(symbols, s)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Symbol < symbols[j].Symbol })
+	return symbols, nil
+}
+
+// Assets returns the base and quote asset of a symbol, e.g. BTC and FDUSD for BTCFDUSD.
+func (r *SymbolRegistry) Assets(pair string) (base, quote string, err error) {
+	s, err := r.Symbol(pair)
+	if err != nil {
+		return "", "", err
+	}
+	return s.BaseAsset, s.QuoteAsset, nil
+}
+
+// Precisions returns the number of decimals used for the base and quote asset of a symbol.
+func (r *SymbolRegistry) Precisions(pair string) (base, quote int, err error) {
+	s, err := r.Symbol(pair)
+	if err != nil {
+		return 0, 0, err
+	}
+	return s.BaseAssetPrecision, s.QuoteAssetPrecision, nil
+}
+
+// OrderTypes returns the order types allowed on a symbol.
+func (r *SymbolRegistry) OrderTypes(pair string) ([]string, error) {
+	s, err := r.Symbol(pair)
+	if err != nil {
+		return nil, err
+	}
+	return s.OrderTypes, nil
+}
+
+func (r *SymbolRegistry) OCOAllowed(pair string) (bool, error) {
+	s, err := r.Symbol(pair)
+	if err != nil {
+		return false, err
+	}
+	return s.OcoAllowed, nil
+}
+
+func (r *SymbolRegistry) TrailingStopAllowed(pair string) (bool, error) {
+	s, err := r.Symbol(pair)
+	if err != nil {
+		return false, err
+	}
+	return s.AllowTrailingStop, nil
+}
+
+// Filters returns the filters of a symbol, see https://binance-docs.github.io/apidocs/spot/en/#filters
+func (r *SymbolRegistry) Filters(pair string) ([]entity.SymbolFilter, error) {
+	s, err := r.Symbol(pair)
+	if err != nil {
+		return nil, err
+	}
+	return s.Filters, nil
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+	"github.com/michelemendel/binance/fakebinance"
+)
+
+func TestSymbolRegistry(t *testing.T) {
+	server, cl := newFakeBinance(t)
+
+	// The first load gets other rate limits, and trailing stops on BTCFDUSD
+	info, err := cl.ExchangeInfo("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info.RateLimits = []entity.RateLimit{
+		{RateLimitType: c.RATE_LIMIT_REQUEST_WEIGHT, Interval: "MINUTE", IntervalNum: 1, Limit: 1200},
+		{RateLimitType: c.RATE_LIMIT_ORDERS, Interval: "SECOND", IntervalNum: 10, Limit: 50},
+		{RateLimitType: c.RATE_LIMIT_ORDERS, Interval: "DAY", IntervalNum: 1, Limit: 160000},
+	}
+	info.Symbols[0].AllowTrailingStop = true
+	body, _ := json.Marshal(info)
+	server.Script(http.MethodGet, c.PATH_EXCHANGE_INFO, fakebinance.Response{Body: string(body)})
+
+	r := NewSymbolRegistry(cl, 100*time.Millisecond)
+	requests := func() int { return len(server.Requests(http.MethodGet, c.PATH_EXCHANGE_INFO)) - 1 }
+
+	tests := []struct {
+		name   string
+		lookup func(pair string) (any, error)
+		pair   string
+		want   any
+	}{
+		{"assets", func(p string) (any, error) { b, q, err := r.Assets(p); return []string{b, q}, err }, "ETHFDUSD", []string{"ETH", "FDUSD"}},
+		{"precisions", func(p string) (any, error) { b, q, err := r.Precisions(p); return []int{b, q}, err }, "BTCFDUSD", []int{8, 8}},
+		{"order types", func(p string) (any, error) { return r.OrderTypes(p) }, "BTCFDUSD",
+			[]string{c.ORDER_TYPE_LIMIT, c.ORDER_TYPE_LIMIT_MAKER, c.ORDER_TYPE_MARKET, c.ORDER_TYPE_STOP_LOSS_LIMIT, c.ORDER_TYPE_TAKE_PROFIT_LIMIT}},
+		{"OCO", func(p string) (any, error) { return r.OCOAllowed(p) }, "BTCFDUSD", true},
+		{"trailing stop", func(p string) (any, error) { return r.TrailingStopAllowed(p) }, "BTCFDUSD", true},
+		{"no trailing stop", func(p string) (any, error) { return r.TrailingStopAllowed(p) }, "ETHFDUSD", false},
+		{"filters", func(p string) (any, error) { f, err := r.Filters(p); return len(f), err }, "ETHFDUSD", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.lookup(tt.pair)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if _, err := r.Symbol("XRPFDUSD"); err == nil {
+		t.Error("unknown symbol: got no error")
+	}
+	if n := requests(); n != 1 {
+		t.Errorf("requests while fresh: got %d, want 1", n)
+	}
+
+	// The limits of the exchange info are handed to the rate limiter
+	u := cl.RateLimiter.Usage()
+	if u.WeightLimit1m != 1200 || u.OrderLimit10s != 50 || u.OrderLimit1d != 160000 {
+		t.Errorf("rate limits: got %+v", u)
+	}
+
+	// Reloaded once older than the refresh interval
+	server.AddSymbol("XRPFDUSD", "XRP", "FDUSD", 0.5)
+	loadedAt := r.LoadedAt()
+	time.Sleep(150 * time.Millisecond)
+	symbols, err := r.Symbols()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(symbols) != 3 || symbols[2].Symbol != "XRPFDUSD" || !r.LoadedAt().After(loadedAt) {
+		t.Errorf("after the refresh interval: got %d symbols, loaded at %v", len(symbols), r.LoadedAt())
+	}
+	if n := requests(); n != 2 {
+		t.Errorf("requests after the refresh interval: got %d, want 2", n)
+	}
+	if u := cl.RateLimiter.Usage(); u.WeightLimit1m != c.DEFAULT_WEIGHT_LIMIT_1M {
+		t.Errorf("rate limits after the refresh: got %+v", u)
+	}
+
+	// The stale symbols are used when the refresh fails
+	loadedAt = r.LoadedAt()
+	time.Sleep(150 * time.Millisecond)
+	unavailable := fakebinance.Error(http.StatusServiceUnavailable, c.ERROR_CODE_UNKNOWN, "Service unavailable")
+	server.Script(http.MethodGet, c.PATH_EXCHANGE_INFO, unavailable, unavailable, unavailable)
+	base, quote, err := r.Assets("XRPFDUSD")
+	if err != nil || base != "XRP" || quote != "FDUSD" {
+		t.Errorf("stale symbols: got %s, %s, %v", base, quote, err)
+	}
+	if !r.LoadedAt().Equal(loadedAt) {
+		t.Errorf("loaded at: got %v, want %v", r.LoadedAt(), loadedAt)
+	}
+}
+
+func TestSymbolRegistryFirstLoadFails(t *testing.T) {
+	server, cl := newFakeBinance(t)
+	unavailable := fakebinance.Error(http.StatusServiceUnavailable, c.ERROR_CODE_UNKNOWN, "Service unavailable")
+	server.Script(http.MethodGet, c.PATH_EXCHANGE_INFO, unavailable, unavailable, unavailable)
+
+	r := NewSymbolRegistry(cl, time.Hour)
+	if _, err := r.Symbol("BTCFDUSD"); err == nil {
+		t.Fatal("got no error")
+	}
+	if !r.LoadedAt().IsZero() {
+		t.Errorf("loaded at: got %v", r.LoadedAt())
+	}
+	// Tried again on the next lookup
+	if _, err := r.Symbol("BTCFDUSD"); err != nil {
+		t.Fatal(err)
+	}
+}
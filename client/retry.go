@@ -0,0 +1,50 @@
+package client
+
+import (
+	"errors"
+	"math/rand"
+	"net/url"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+)
+
+// RetryPolicy is used for requests that fail because of the network or the server.
+// GET requests are retried as is, new orders are first looked up by their client order id, see Client.Order.
+type RetryPolicy struct {
+	MaxAttempts int // Including the first attempt
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: c.RETRY_MAX_ATTEMPTS,
+		BaseDelay:   c.RETRY_BASE_DELAY,
+		MaxDelay:    c.RETRY_MAX_DELAY,
+	}
+}
+
+// Backoff returns a random delay between 0 and BaseDelay*2^(attempt-1), capped at MaxDelay ("full jitter").
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.MaxDelay
+	if attempt < 32 {
+		if d := p.BaseDelay << (attempt - 1); d > 0 && d < p.MaxDelay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay)))
+}
+
+// isRetryable reports whether the request failed because of the network or the server, rather than the request itself.
+// For requests that change state, the request may still have been executed.
+func isRetryable(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) || errors.Is(err, errWSAPINoResponse) {
+		return true
+	}
+	return IsServerError(err) || IsAPIError(err, c.ERROR_CODE_DISCONNECTED, c.ERROR_CODE_TIMEOUT)
+}
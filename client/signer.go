@@ -0,0 +1,105 @@
+package client
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	c "github.com/michelemendel/binance/constant"
+)
+
+// Signer signs the payload of TRADE and USER_DATA requests.
+// https://binance-docs.github.io/apidocs/spot/en/#signed-trade-user_data-and-margin-endpoint-security
+type Signer interface {
+	Sign(payload string) (string, error)
+}
+
+// NewSigner returns the signer for the key type, HMAC, RSA or ED25519.
+// HMAC uses the secret key, RSA and ED25519 use a PKCS#8 PEM encoded private key.
+func NewSigner(keyType, secretKey string, privateKeyPEM []byte) (Signer, error) {
+	switch keyType {
+	case c.KEY_TYPE_HMAC, "":
+		return HMACSigner{SecretKey: secretKey}, nil
+	case c.KEY_TYPE_RSA:
+		return NewRSASigner(privateKeyPEM)
+	case c.KEY_TYPE_ED25519:
+		return NewEd25519Signer(privateKeyPEM)
+	default:
+		return nil, fmt.Errorf("unknown key type %q", keyType)
+	}
+}
+
+// HMACSigner signs with HMAC-SHA256, the signature is hex encoded.
+type HMACSigner struct {
+	SecretKey string
+}
+
+func (s HMACSigner) Sign(payload string) (string, error) {
+	return HMACSign(s.SecretKey, payload), nil
+}
+
+// RSASigner signs with RSASSA-PKCS1-v1_5 and SHA-256, the signature is base64 encoded.
+type RSASigner struct {
+	key *rsa.PrivateKey
+}
+
+func NewRSASigner(privateKeyPEM []byte) (*RSASigner, error) {
+	key, err := parsePKCS8(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is %T, not an RSA key", key)
+	}
+	return &RSASigner{key: rsaKey}, nil
+}
+
+func (s *RSASigner) Sign(payload string) (string, error) {
+	hashed := sha256.Sum256([]byte(payload))
+	signed, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", fmt.Errorf("error signing with RSA key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(signed), nil
+}
+
+// Ed25519Signer signs with Ed25519, the signature is base64 encoded.
+type Ed25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+func NewEd25519Signer(privateKeyPEM []byte) (*Ed25519Signer, error) {
+	key, err := parsePKCS8(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is %T, not an Ed25519 key", key)
+	}
+	return &Ed25519Signer{key: edKey}, nil
+}
+
+func (s *Ed25519Signer) Sign(payload string) (string, error) {
+	signed := ed25519.Sign(s.key, []byte(payload))
+	return base64.StdEncoding.EncodeToString(signed), nil
+}
+
+func parsePKCS8(privateKeyPEM []byte) (any, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing PKCS#8 private key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,498 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/util"
+)
+
+// StreamMessage is an event of a combined stream, e.g. {"stream":"btcfdusd@miniTicker","data":{...}}
+type StreamMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// StreamError is the error of a SUBSCRIBE, UNSUBSCRIBE or LIST_SUBSCRIPTIONS request.
+type StreamError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("<StreamError> code=%d, msg=%s", e.Code, e.Msg)
+}
+
+// streamFrame is anything received on a combined stream connection: an event, or the response to a request.
+type streamFrame struct {
+	StreamMessage
+	Id     *int64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *StreamError    `json:"error"`
+}
+
+type streamRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params,omitempty"`
+	Id     int64    `json:"id"`
+}
+
+// StreamManager keeps combined stream connections to the market streams open, and delivers their events on one channel.
+// Streams can be added and removed while connected. They are spread over as many connections as needed
+// to stay within the limit of streams per connection.
+// Dropped connections are reopened with backoff, and connections are replaced before
+// the server closes them at 24 hours, with all their streams subscribed again.
+// https://binance-docs.github.io/apidocs/spot/en/#websocket-market-streams
+type StreamManager struct {
+	baseWS     string
+	retry      RetryPolicy
+	lifetime   time.Duration // Connections are replaced after this long
+	maxStreams int           // Streams per connection
+	messages   chan StreamMessage
+
+	mu      sync.Mutex
+	ctx     context.Context // Set by Start
+	closed  bool            // Set when the context is cancelled, no connections are opened after that
+	wg      sync.WaitGroup  // Running connections
+	shards  []*streamShard
+	streams map[string]*streamShard
+}
+
+// NewStreamManager returns a manager of the streams, e.g. "btcfdusd@miniTicker", at baseWS.
+func NewStreamManager(baseWS string, retry RetryPolicy, streams ...string) *StreamManager {
+	m := &StreamManager{
+		baseWS:     baseWS,
+		retry:      retry,
+		lifetime:   c.WS_CONNECTION_LIFETIME,
+		maxStreams: c.WS_MAX_STREAMS,
+		messages:   make(chan StreamMessage, c.WS_BUFFER_SIZE),
+		streams:    map[string]*streamShard{},
+	}
+	m.assign(streams)
+	return m
+}
+
+// Stream returns a manager of the market streams, e.g. client.Stream("btcfdusd@miniTicker", "ethfdusd@miniTicker").
+func (client Client) Stream(streams ...string) *StreamManager {
+	return NewStreamManager(client.BaseWS, client.Retry, streams...)
+}
+
+// Streams returns the names of the streams, sorted.
+func (m *StreamManager) Streams() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	streams := make([]string, 0, len(m.streams))
+	for s := range m.streams {
+		streams = append(streams, s)
+	}
+	sort.Strings(streams)
+	return streams
+}
+
+// Start connects and returns the channel the events are delivered on.
+// The channel is closed when the context is cancelled.
+func (m *StreamManager) Start(ctx context.Context) <-chan StreamMessage {
+	m.mu.Lock()
+	m.ctx = ctx
+	for _, s := range m.shards {
+		m.startShard(s)
+	}
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		m.closed = true
+		m.mu.Unlock()
+		m.wg.Wait()
+		close(m.messages)
+	}()
+	return m.messages
+}
+
+// Subscribe adds streams. Before Start they are only recorded, after Start they are subscribed to
+// on a connection with room for them, opening a new connection if there's none.
+// Streams already subscribed to are ignored.
+func (m *StreamManager) Subscribe(ctx context.Context, streams ...string) error {
+	m.mu.Lock()
+	groups := m.assign(streams)
+	for s := range groups {
+		if m.ctx != nil && !s.started {
+			m.startShard(s)
+		}
+	}
+	m.mu.Unlock()
+
+	for s, group := range groups {
+		err := s.request(ctx, c.WS_METHOD_SUBSCRIBE, group, nil)
+		if err != nil {
+			return fmt.Errorf("error subscribing to %v: %w", group, err)
+		}
+	}
+	return nil
+}
+
+// Unsubscribe removes streams. Streams not subscribed to are ignored.
+func (m *StreamManager) Unsubscribe(ctx context.Context, streams ...string) error {
+	groups := map[*streamShard][]string{}
+	m.mu.Lock()
+	for _, stream := range streams {
+		s, ok := m.streams[stream]
+		if !ok {
+			continue
+		}
+		delete(m.streams, stream)
+		s.remove(stream)
+		groups[s] = append(groups[s], stream)
+	}
+	m.mu.Unlock()
+
+	for s, group := range groups {
+		err := s.request(ctx, c.WS_METHOD_UNSUBSCRIBE, group, nil)
+		if err != nil {
+			return fmt.Errorf("error unsubscribing from %v: %w", group, err)
+		}
+	}
+	return nil
+}
+
+// ListSubscriptions asks the server which streams the connections are subscribed to.
+// Connections that aren't open are skipped.
+func (m *StreamManager) ListSubscriptions(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	shards := append([]*streamShard{}, m.shards...)
+	m.mu.Unlock()
+
+	var streams []string
+	for _, s := range shards {
+		var result []string
+		err := s.request(ctx, c.WS_METHOD_LIST_SUBSCRIPTIONS, nil, &result)
+		if err != nil {
+			return nil, fmt.Errorf("error listing subscriptions: %w", err)
+		}
+		streams = append(streams, result...)
+	}
+	sort.Strings(streams)
+	return streams, nil
+}
+
+// assign adds new streams to shards with room for them, creating shards as needed,
+// and returns the new streams by shard. The caller holds m.mu, or has the only reference to m.
+func (m *StreamManager) assign(streams []string) map[*streamShard][]string {
+	groups := map[*streamShard][]string{}
+	for _, stream := range streams {
+		if _, ok := m.streams[stream]; ok {
+			continue
+		}
+		var shard *streamShard
+		for _, s := range m.shards {
+			if s.count() < m.maxStreams {
+				shard = s
+				break
+			}
+		}
+		if shard == nil {
+			shard = newStreamShard(m, len(m.shards))
+			m.shards = append(m.shards, shard)
+		}
+		shard.add(stream)
+		m.streams[stream] = shard
+		groups[shard] = append(groups[shard], stream)
+	}
+	return groups
+}
+
+// startShard starts a shard's connection, unless the manager is closed. The caller holds m.mu.
+func (m *StreamManager) startShard(s *streamShard) {
+	if m.closed || s.started {
+		return
+	}
+	s.started = true
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		s.run(m.ctx)
+	}()
+}
+
+// streamShard is one connection of a StreamManager and the streams subscribed to on it.
+type streamShard struct {
+	m  *StreamManager
+	no int // For logging
+
+	started bool // Guarded by m.mu
+
+	mu       sync.Mutex
+	streams  map[string]bool
+	conn     *websocket.Conn // Nil while disconnected
+	nextId   int64
+	pending  map[int64]chan streamFrame
+	lastSent time.Time
+}
+
+func newStreamShard(m *StreamManager, no int) *streamShard {
+	return &streamShard{
+		m:       m,
+		no:      no,
+		streams: map[string]bool{},
+		pending: map[int64]chan streamFrame{},
+	}
+}
+
+func (s *streamShard) add(stream string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams[stream] = true
+}
+
+func (s *streamShard) remove(stream string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.streams, stream)
+}
+
+func (s *streamShard) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.streams)
+}
+
+// request sends a request and waits for the response, decoding its result into result, if not nil.
+// If the shard isn't connected, nothing is sent and nil is returned: the streams are subscribed to when it connects.
+func (s *streamShard) request(ctx context.Context, method string, params []string, result any) error {
+	s.mu.Lock()
+	if s.conn == nil {
+		s.mu.Unlock()
+		return nil
+	}
+	s.nextId++
+	id := s.nextId
+	resp := make(chan streamFrame, 1)
+	s.pending[id] = resp
+	err := s.send(s.conn, streamRequest{Method: method, Params: params, Id: id})
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+	if err != nil {
+		return err
+	}
+
+	timeout := time.NewTimer(c.TIMEOUT)
+	defer timeout.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout.C:
+		return fmt.Errorf("no response to %s %d within %v", method, id, c.TIMEOUT)
+	case f := <-resp:
+		if f.Error != nil {
+			return f.Error
+		}
+		if result != nil {
+			return json.Unmarshal(f.Result, result)
+		}
+		return nil
+	}
+}
+
+// send writes a request, spacing messages to stay within the limit of messages per second.
+// The caller holds s.mu.
+func (s *streamShard) send(conn *websocket.Conn, req streamRequest) error {
+	if wait := time.Until(s.lastSent.Add(time.Second / c.WS_MAX_MESSAGES_PER_SECOND)); wait > 0 {
+		time.Sleep(wait)
+	}
+	s.lastSent = time.Now()
+	err := conn.SetWriteDeadline(time.Now().Add(c.TIMEOUT))
+	if err != nil {
+		return err
+	}
+	return conn.WriteJSON(req)
+}
+
+// connect opens a connection, subscribes to the shard's streams and makes it the shard's connection.
+func (s *streamShard) connect(ctx context.Context) (*websocket.Conn, <-chan error, error) {
+	dialer := websocket.Dialer{HandshakeTimeout: c.TIMEOUT}
+	u := s.m.baseWS + "/stream"
+	conn, _, err := dialer.DialContext(ctx, u, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error connecting to %s: %w", u, err)
+	}
+	done := s.read(ctx, conn)
+
+	// Holding the lock until the connection is in place, so streams added meanwhile are either
+	// in this subscription or sent on the new connection
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	streams := make([]string, 0, len(s.streams))
+	for stream := range s.streams {
+		streams = append(streams, stream)
+	}
+	sort.Strings(streams)
+	if len(streams) > 0 {
+		s.nextId++
+		err = s.send(conn, streamRequest{Method: c.WS_METHOD_SUBSCRIBE, Params: streams, Id: s.nextId})
+		if err != nil {
+			conn.Close()
+			<-done
+			return nil, nil, fmt.Errorf("error subscribing: %w", err)
+		}
+	}
+	s.conn = conn
+	slog.Info("connected stream", "connection", s.no, "streams", len(streams))
+	return conn, done, nil
+}
+
+func (s *streamShard) disconnect(conn *websocket.Conn) {
+	s.mu.Lock()
+	if s.conn == conn {
+		s.conn = nil
+	}
+	s.mu.Unlock()
+	conn.Close()
+}
+
+func (s *streamShard) run(ctx context.Context) {
+	var conn *websocket.Conn
+	var done <-chan error
+	roll := time.NewTimer(s.m.lifetime)
+	defer roll.Stop()
+
+	for attempt := 0; ; {
+		if conn == nil {
+			if attempt > 0 {
+				delay := s.m.retry.Backoff(attempt)
+				slog.Warn("reconnecting stream", "connection", s.no, "attempt", attempt, "delay", delay)
+				if !util.SleepCtx(ctx, delay) {
+					return
+				}
+			}
+			var err error
+			conn, done, err = s.connect(ctx)
+			if err != nil {
+				slog.Error("error connecting stream", "connection", s.no, "error", err)
+				attempt++
+				continue
+			}
+			attempt = 0
+			resetTimer(roll, s.m.lifetime)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.disconnect(conn)
+			<-done
+			return
+
+		case err := <-done:
+			slog.Warn("stream disconnected", "connection", s.no, "error", err)
+			s.disconnect(conn)
+			conn = nil
+			attempt++
+
+		case <-roll.C:
+			// The new connection is opened before the old one is closed, so no events are missed.
+			// Events received on both in the meantime are delivered twice.
+			next, nextDone, err := s.connect(ctx)
+			if err != nil {
+				slog.Error("error replacing stream connection", "connection", s.no, "error", err)
+				resetTimer(roll, c.WS_ROLL_RETRY)
+				continue
+			}
+			old, oldDone := conn, done
+			conn, done = next, nextDone
+			old.Close()
+			<-oldDone
+			resetTimer(roll, s.m.lifetime)
+			slog.Info("replaced stream connection", "connection", s.no)
+		}
+	}
+}
+
+// read delivers the events of the connection and the responses to requests until it fails or is closed,
+// and then sends the error on the returned channel.
+// The server pings every 3 minutes and disconnects if no pong is received within 10 minutes;
+// the connection is considered dead if nothing, not even a ping, is received within WS_READ_TIMEOUT.
+func (s *streamShard) read(ctx context.Context, conn *websocket.Conn) <-chan error {
+	done := make(chan error, 1)
+	handlePings(conn)
+
+	go func() {
+		for {
+			err := conn.SetReadDeadline(time.Now().Add(c.WS_READ_TIMEOUT))
+			if err != nil {
+				done <- err
+				return
+			}
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				done <- err
+				return
+			}
+
+			var f streamFrame
+			err = json.Unmarshal(data, &f)
+			if err != nil {
+				slog.Error("error decoding stream message", "message", string(data), "error", err)
+				continue
+			}
+			if f.Id != nil {
+				s.respond(f)
+				continue
+			}
+			select {
+			case s.m.messages <- f.StreamMessage:
+			case <-ctx.Done():
+				done <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// respond passes a response to the request waiting for it. Responses nobody waits for, e.g. to the
+// subscription made when connecting, are only logged if they're errors.
+func (s *streamShard) respond(f streamFrame) {
+	s.mu.Lock()
+	resp, ok := s.pending[*f.Id]
+	s.mu.Unlock()
+	if ok {
+		resp <- f
+		return
+	}
+	if f.Error != nil {
+		slog.Error("stream request failed", "connection", s.no, "id", *f.Id, "error", f.Error)
+	}
+}
+
+// handlePings answers the server's pings, and extends the read deadline on each of them.
+func handlePings(conn *websocket.Conn) {
+	conn.SetPingHandler(func(data string) error {
+		err := conn.SetReadDeadline(time.Now().Add(c.WS_READ_TIMEOUT))
+		if err != nil {
+			return err
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(c.TIMEOUT))
+	})
+}
+
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	c "github.com/michelemendel/binance/constant"
+)
+
+// fakeStreamServer answers SUBSCRIBE, UNSUBSCRIBE and LIST_SUBSCRIPTIONS requests on each connection,
+// and calls onSubscribed with the connection number after the first subscription.
+type fakeStreamServer struct {
+	*httptest.Server
+	connections  atomic.Int32
+	onSubscribed func(n int32, conn *websocket.Conn) (keep bool)
+}
+
+func newFakeStreamServer(t *testing.T, onSubscribed func(n int32, conn *websocket.Conn) bool) *fakeStreamServer {
+	s := &fakeStreamServer{onSubscribed: onSubscribed}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		n := s.connections.Add(1)
+		subscribed := map[string]bool{}
+		for first := true; ; first = false {
+			var req streamRequest
+			err := conn.ReadJSON(&req)
+			if err != nil {
+				return
+			}
+			var result any
+			switch req.Method {
+			case c.WS_METHOD_SUBSCRIBE:
+				for _, p := range req.Params {
+					subscribed[p] = true
+				}
+			case c.WS_METHOD_UNSUBSCRIBE:
+				for _, p := range req.Params {
+					delete(subscribed, p)
+				}
+			case c.WS_METHOD_LIST_SUBSCRIPTIONS:
+				list := []string{}
+				for p := range subscribed {
+					list = append(list, p)
+				}
+				result = list
+			}
+			err = conn.WriteJSON(map[string]any{"result": result, "id": req.Id})
+			if err != nil {
+				return
+			}
+			if first && s.onSubscribed != nil && !s.onSubscribed(n, conn) {
+				return
+			}
+		}
+	}))
+	return s
+}
+
+func (s *fakeStreamServer) wsURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+var testRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+func TestStreamManagerReconnects(t *testing.T) {
+	// Each connection sends one event; the first one is then dropped, the others are kept open
+	server := newFakeStreamServer(t, func(n int32, conn *websocket.Conn) bool {
+		msg := fmt.Sprintf(`{"stream":"btcfdusd@miniTicker","data":{"c":"%d"}}`, n)
+		err := conn.WriteMessage(websocket.TextMessage, []byte(msg))
+		return err == nil && n > 1
+	})
+	defer server.Close()
+
+	m := NewStreamManager(server.wsURL(), testRetry, "btcfdusd@miniTicker", "ethfdusd@miniTicker")
+	m.lifetime = 200 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	messages := m.Start(ctx)
+
+	// 1: first connection, 2: reconnected after the drop, 3: replaced at the end of its lifetime
+	for want := 1; want <= 3; want++ {
+		msg, ok := <-messages
+		if !ok {
+			t.Fatalf("channel closed before message %d", want)
+		}
+		if msg.Stream != "btcfdusd@miniTicker" || string(msg.Data) != fmt.Sprintf(`{"c":"%d"}`, want) {
+			t.Fatalf("message %d: got %s %s", want, msg.Stream, msg.Data)
+		}
+	}
+
+	cancel()
+	for range messages {
+	}
+}
+
+func TestStreamManagerSubscribe(t *testing.T) {
+	server := newFakeStreamServer(t, nil)
+	defer server.Close()
+
+	m := NewStreamManager(server.wsURL(), testRetry)
+	m.maxStreams = 2
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// Before Start the streams are only recorded
+	err := m.Subscribe(ctx, "a", "b", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := m.Start(ctx)
+
+	// Waits until all connections are open and subscribed
+	waitForList := func(want []string) {
+		t.Helper()
+		var got []string
+		for i := 0; i < 100; i++ {
+			var err error
+			got, err = m.ListSubscriptions(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reflect.DeepEqual(got, want) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("subscriptions: got %v, want %v", got, want)
+	}
+	waitForList([]string{"a", "b", "c"})
+
+	err = m.Subscribe(ctx, "c", "d", "e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForList([]string{"a", "b", "c", "d", "e"})
+	if n := server.connections.Load(); n != 3 {
+		t.Errorf("connections: got %d, want 3", n)
+	}
+
+	err = m.Unsubscribe(ctx, "a", "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForList([]string{"b", "c", "d", "e"})
+	if got := m.Streams(); !reflect.DeepEqual(got, []string{"b", "c", "d", "e"}) {
+		t.Errorf("streams: got %v", got)
+	}
+
+	cancel()
+	for range messages {
+	}
+}
+
+func TestStreamManagerSendRate(t *testing.T) {
+	server := newFakeStreamServer(t, nil)
+	defer server.Close()
+
+	m := NewStreamManager(server.wsURL(), testRetry, "a")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m.Start(ctx)
+
+	for {
+		list, err := m.ListSubscriptions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		_, err := m.ListSubscriptions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < time.Second-10*time.Millisecond {
+		t.Errorf("5 requests took %v, want at least 1s", elapsed)
+	}
+}
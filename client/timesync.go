@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+)
+
+// TimeSync estimates the offset between the local clock and the server clock,
+// so signed requests get a timestamp the server accepts, even if the local clock drifts.
+// https://binance-docs.github.io/apidocs/spot/en/#timing-security
+type TimeSync struct {
+	client   *Client
+	interval time.Duration
+
+	mu       sync.RWMutex
+	offset   time.Duration // Server time minus local time
+	latency  time.Duration // Round trip of the best sample
+	syncedAt time.Time
+	failures int           // Failed syncs in a row
+	retryAt  time.Time     // After a failed sync, Now doesn't sync again before this
+	syncing  chan struct{} // Closed when the sync in progress is done, nil if there's none
+	err      error         // Of the last sync
+}
+
+func NewTimeSync(client *Client, interval time.Duration) *TimeSync {
+	return &TimeSync{
+		client:   client,
+		interval: interval,
+	}
+}
+
+// Sync samples the server time a few times and keeps the sample with the lowest round trip,
+// assuming the server read its clock halfway through it.
+// If a sync is already in progress, it waits for that one and returns its result instead.
+func (t *TimeSync) Sync() error {
+	return t.sync(false)
+}
+
+// sync syncs, or waits for the sync in progress. If ifDue, it doesn't sync if Now doesn't need to.
+func (t *TimeSync) sync(ifDue bool) error {
+	t.mu.Lock()
+	if ifDue && !t.due() {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.syncing != nil {
+		done := t.syncing
+		t.mu.Unlock()
+		<-done
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+		return t.err
+	}
+	done := make(chan struct{})
+	t.syncing = done
+	t.mu.Unlock()
+
+	offset, latency, err := t.sample()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.err = err
+	t.syncing = nil
+	close(done)
+	if err != nil {
+		t.failures++
+		t.retryAt = time.Now().Add(t.retryDelay())
+		return err
+	}
+	t.offset = offset
+	t.latency = latency
+	t.syncedAt = time.Now()
+	t.failures = 0
+	t.retryAt = time.Time{}
+	slog.Info("synced server time", "offset", offset, "latency", latency)
+	return nil
+}
+
+// retryDelay is TIME_SYNC_RETRY doubled for each failure in a row after the first, capped at the interval.
+func (t *TimeSync) retryDelay() time.Duration {
+	delay := t.interval
+	if t.failures < 32 {
+		if d := c.TIME_SYNC_RETRY << (t.failures - 1); d > 0 && d < t.interval {
+			delay = d
+		}
+	}
+	return delay
+}
+
+// sample returns the offset and latency of the sample with the lowest round trip.
+func (t *TimeSync) sample() (time.Duration, time.Duration, error) {
+	var bestOffset, bestLatency time.Duration
+	found := false
+
+	for i := 0; i < c.TIME_SYNC_SAMPLES; i++ {
+		sent := time.Now()
+		resp, err := t.client.Time()
+		if err != nil {
+			// The request has been retried already, so the remaining samples would most likely fail too
+			slog.Error("error sampling server time", "error", err)
+			break
+		}
+		received := time.Now()
+
+		latency := received.Sub(sent)
+		serverTime := time.UnixMilli(int64(resp.ServerTime))
+		offset := serverTime.Sub(sent.Add(latency / 2))
+		if !found || latency < bestLatency {
+			bestOffset, bestLatency, found = offset, latency, true
+		}
+	}
+	if !found {
+		return 0, 0, fmt.Errorf("error syncing time: no samples")
+	}
+	return bestOffset, bestLatency, nil
+}
+
+// Start syncs periodically until the context is cancelled.
+func (t *TimeSync) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+		for {
+			err := t.Sync()
+			if err != nil {
+				slog.Error("error syncing time", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Now returns the estimated server time in milliseconds.
+// It syncs first if it never has, or if the last sync is older than the interval.
+// If the sync fails, the last known offset is used, and no sync is tried again until the retry delay has passed,
+// so signed requests don't each wait for a sync while the server can't be reached.
+func (t *TimeSync) Now() int64 {
+	t.mu.RLock()
+	due := t.due()
+	t.mu.RUnlock()
+
+	if due {
+		err := t.sync(true)
+		if err != nil {
+			slog.Error("using last known time offset", "offset", t.Offset(), "error", err)
+		}
+	}
+	return time.Now().Add(t.Offset()).UnixMilli()
+}
+
+// due tells if the last sync is older than the interval, and the retry delay after a failed sync has passed.
+// The caller holds the lock.
+func (t *TimeSync) due() bool {
+	return time.Since(t.syncedAt) > t.interval && !time.Now().Before(t.retryAt)
+}
+
+// Offset returns the server time minus the local time.
+func (t *TimeSync) Offset() time.Duration {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.offset
+}
+
+// Latency returns the round trip of the request the offset was estimated from.
+func (t *TimeSync) Latency() time.Duration {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.latency
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+)
+
+func TestTimeSyncOutage(t *testing.T) {
+	var requests atomic.Int32
+	var down atomic.Bool
+	down.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		// Slow enough for the concurrent callers to find the sync in progress
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprintf(w, `{"serverTime":%d}`, time.Now().Add(time.Hour).UnixMilli())
+	}))
+	defer server.Close()
+	cl := NewClient("test", nil, "", "", server.URL, "")
+	cl.Retry = testRetry
+
+	// Concurrent callers share one sync, which gives up after the first sample fails
+	nowAll := func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cl.TimeSync.Now()
+			}()
+		}
+		wg.Wait()
+	}
+	nowAll()
+	if n := requests.Load(); n != int32(testRetry.MaxAttempts) {
+		t.Errorf("requests while down: got %d, want %d", n, testRetry.MaxAttempts)
+	}
+
+	// No sync is tried again before the retry delay
+	cl.TimeSync.Now()
+	if n := requests.Load(); n != int32(testRetry.MaxAttempts) {
+		t.Errorf("requests before the retry delay: got %d, want %d", n, testRetry.MaxAttempts)
+	}
+	cl.TimeSync.mu.Lock()
+	failures, retryIn := cl.TimeSync.failures, time.Until(cl.TimeSync.retryAt)
+	cl.TimeSync.mu.Unlock()
+	if failures != 1 || retryIn <= 0 || retryIn > c.TIME_SYNC_RETRY {
+		t.Errorf("got %d failures and a retry in %v", failures, retryIn)
+	}
+
+	// Synced once the retry delay has passed
+	down.Store(false)
+	requests.Store(0)
+	cl.TimeSync.mu.Lock()
+	cl.TimeSync.retryAt = time.Now()
+	cl.TimeSync.mu.Unlock()
+	nowAll()
+	if n := requests.Load(); n != c.TIME_SYNC_SAMPLES {
+		t.Errorf("requests when up: got %d, want %d", n, c.TIME_SYNC_SAMPLES)
+	}
+	if offset := cl.TimeSync.Offset(); offset < 59*time.Minute {
+		t.Errorf("offset: got %v, want about an hour", offset)
+	}
+}
@@ -0,0 +1,247 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gorilla/websocket"
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+	"github.com/michelemendel/binance/util"
+)
+
+var errListenKeyExpired = errors.New("listen key expired")
+
+// UserDataStream keeps a connection to the user data stream open, and delivers its events on one channel,
+// so order updates and balance changes are pushed instead of polled.
+// It creates a listenKey, keeps it alive every 30 minutes, and closes it when the context is cancelled.
+// Dropped connections are reopened with backoff, with a new listenKey if the old one has expired,
+// and the connection is replaced before the server closes it at 24 hours.
+// Fills reported by execution reports are added to the client's journal, if it has one.
+// https://binance-docs.github.io/apidocs/spot/en/#user-data-streams
+type UserDataStream struct {
+	client    Client
+	keepAlive time.Duration // How often the listenKey is kept alive
+	lifetime  time.Duration // Connections are replaced after this long
+	events    chan entity.UserDataEvent
+}
+
+// UserDataStream returns a user data stream of the client's account.
+func (client Client) UserDataStream() *UserDataStream {
+	return &UserDataStream{
+		client:    client,
+		keepAlive: c.LISTEN_KEY_KEEPALIVE,
+		lifetime:  c.WS_CONNECTION_LIFETIME,
+		events:    make(chan entity.UserDataEvent, c.WS_BUFFER_SIZE),
+	}
+}
+
+// Start connects and returns the channel the events are delivered on.
+// The channel is closed when the context is cancelled.
+func (u *UserDataStream) Start(ctx context.Context) <-chan entity.UserDataEvent {
+	go u.run(ctx)
+	return u.events
+}
+
+func (u *UserDataStream) run(ctx context.Context) {
+	defer close(u.events)
+	var listenKey string
+	var conn *websocket.Conn
+	var done <-chan error
+	keepAlive := time.NewTicker(u.keepAlive)
+	defer keepAlive.Stop()
+	roll := time.NewTimer(u.lifetime)
+	defer roll.Stop()
+
+	for attempt := 0; ; {
+		if conn == nil {
+			if attempt > 0 {
+				delay := u.client.Retry.Backoff(attempt)
+				slog.Warn("reconnecting user data stream", "attempt", attempt, "delay", delay)
+				if !util.SleepCtx(ctx, delay) {
+					u.close(listenKey)
+					return
+				}
+				// The listenKey may have expired while disconnected
+				if listenKey != "" && u.expired(u.client.KeepAliveListenKey(listenKey)) {
+					listenKey = ""
+				}
+			}
+			var err error
+			if listenKey == "" {
+				listenKey, err = u.client.CreateListenKey()
+				if err != nil {
+					slog.Error("error creating listen key", "error", err)
+					attempt++
+					continue
+				}
+				keepAlive.Reset(u.keepAlive)
+			}
+			conn, done, err = u.connect(ctx, listenKey)
+			if err != nil {
+				slog.Error("error connecting user data stream", "error", err)
+				attempt++
+				continue
+			}
+			attempt = 0
+			resetTimer(roll, u.lifetime)
+		}
+
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			<-done
+			u.close(listenKey)
+			return
+
+		case err := <-done:
+			conn.Close()
+			conn = nil
+			if errors.Is(err, errListenKeyExpired) {
+				slog.Warn("listen key expired, creating a new one")
+				listenKey = ""
+				continue
+			}
+			slog.Warn("user data stream disconnected", "error", err)
+			attempt++
+
+		case <-keepAlive.C:
+			err := u.client.KeepAliveListenKey(listenKey)
+			if err == nil {
+				continue
+			}
+			if u.expired(err) {
+				// No more events are sent for the listenKey, so the connection is replaced with one for a new key
+				slog.Warn("listen key expired, creating a new one")
+				conn.Close()
+				<-done
+				conn = nil
+				listenKey = ""
+				continue
+			}
+			// The listenKey is valid for another 30 minutes, so it's kept alive at the next tick
+			slog.Error("error keeping listen key alive", "error", err)
+
+		case <-roll.C:
+			// The new connection is opened before the old one is closed, so no events are missed.
+			// Events received on both in the meantime are delivered twice.
+			next, nextDone, err := u.connect(ctx, listenKey)
+			if err != nil {
+				slog.Error("error replacing user data stream connection", "error", err)
+				resetTimer(roll, c.WS_ROLL_RETRY)
+				continue
+			}
+			old, oldDone := conn, done
+			conn, done = next, nextDone
+			old.Close()
+			<-oldDone
+			resetTimer(roll, u.lifetime)
+			slog.Info("replaced user data stream connection")
+		}
+	}
+}
+
+// expired tells if the error is Binance saying the listenKey doesn't exist.
+func (u *UserDataStream) expired(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == c.ERROR_CODE_INVALID_LISTEN_KEY
+}
+
+func (u *UserDataStream) close(listenKey string) {
+	if listenKey == "" {
+		return
+	}
+	err := u.client.CloseListenKey(listenKey)
+	if err != nil {
+		slog.Error("error closing user data stream", "error", err)
+	}
+}
+
+func (u *UserDataStream) connect(ctx context.Context, listenKey string) (*websocket.Conn, <-chan error, error) {
+	dialer := websocket.Dialer{HandshakeTimeout: c.TIMEOUT}
+	conn, _, err := dialer.DialContext(ctx, u.client.BaseWS+"/ws/"+listenKey, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error connecting to %s/ws: %w", u.client.BaseWS, err)
+	}
+	slog.Info("connected user data stream")
+	return conn, u.read(ctx, conn), nil
+}
+
+// read delivers the events of the connection until it fails, is closed or the listenKey expires,
+// and then sends the error on the returned channel. See streamShard.read.
+func (u *UserDataStream) read(ctx context.Context, conn *websocket.Conn) <-chan error {
+	done := make(chan error, 1)
+	handlePings(conn)
+
+	go func() {
+		for {
+			err := conn.SetReadDeadline(time.Now().Add(c.WS_READ_TIMEOUT))
+			if err != nil {
+				done <- err
+				return
+			}
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				done <- err
+				return
+			}
+
+			e, err := DecodeUserDataEvent(data)
+			if err != nil {
+				slog.Error("error decoding user data event", "error", err)
+				continue
+			}
+			if r, ok := e.(entity.ExecutionReport); ok && r.ExecutionType == c.EXECUTION_TYPE_TRADE {
+				u.client.recordFills([]entity.Trade{tradeFromExecutionReport(r)})
+			}
+			select {
+			case u.events <- e:
+			case <-ctx.Done():
+				done <- ctx.Err()
+				return
+			}
+			if e.EventType() == c.EVENT_LISTEN_KEY_EXPIRED {
+				done <- errListenKeyExpired
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// DecodeUserDataEvent decodes an event of the user data stream into its type, which is found from the "e" field.
+func DecodeUserDataEvent(data []byte) (entity.UserDataEvent, error) {
+	// "E" has to be a field, or it's matched to "e", since field names are matched case-insensitively
+	var head struct {
+		Event string `json:"e"`
+		Time  int64  `json:"E"`
+	}
+	err := json.Unmarshal(data, &head)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding user data event: %w", err)
+	}
+	switch head.Event {
+	case c.EVENT_EXECUTION_REPORT:
+		return decodeUserDataEvent[entity.ExecutionReport](data)
+	case c.EVENT_ACCOUNT_POSITION:
+		return decodeUserDataEvent[entity.AccountPositionEvent](data)
+	case c.EVENT_BALANCE_UPDATE:
+		return decodeUserDataEvent[entity.BalanceUpdateEvent](data)
+	case c.EVENT_LISTEN_KEY_EXPIRED:
+		return decodeUserDataEvent[entity.ListenKeyExpiredEvent](data)
+	}
+	return nil, fmt.Errorf("unknown user data event %s", head.Event)
+}
+
+func decodeUserDataEvent[T entity.UserDataEvent](data []byte) (entity.UserDataEvent, error) {
+	var e T
+	err := json.Unmarshal(data, &e)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding user data event: %w", err)
+	}
+	return e, nil
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+	"github.com/michelemendel/binance/journal"
+)
+
+func TestUserDataStream(t *testing.T) {
+	var mu sync.Mutex
+	var created int
+	var keptAlive, closed []string
+
+	// The first listenKey expires after one order update, the second one gets the balance changes
+	events := map[string][]string{
+		"key1": {
+			`{"e":"executionReport","E":1,"s":"BTCFDUSD","c":"abc","S":"BUY","o":"MARKET","q":"0.001","p":"0","x":"TRADE","X":"FILLED","i":42,"l":"0.001","z":"0.001","L":"40000","n":"0","N":"BNB","T":2,"t":7,"I":99,"w":false,"m":false,"M":true}`,
+			// Expired by self-trade prevention
+			`{"e":"executionReport","E":2,"s":"BTCFDUSD","c":"def","S":"SELL","o":"LIMIT","f":"GTC","q":"0.001","p":"40000","x":"TRADE_PREVENTION","X":"EXPIRED","i":43,"T":2,"t":-1,"v":3,"A":"0.001","B":"0.001","u":1,"U":42,"I":100,"V":"EXPIRE_MAKER"}`,
+			`{"e":"listenKeyExpired","E":3,"listenKey":"key1"}`,
+		},
+		"key2": {
+			`{"e":"outboundAccountPosition","E":4,"u":4,"B":[{"a":"BTC","f":"0.001","l":"0"}]}`,
+			`{"e":"listStatus","E":5}`,
+			`{"e":"balanceUpdate","E":6,"a":"FDUSD","d":"-40","T":6}`,
+		},
+	}
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-MBX-APIKEY") != "apikey" && r.URL.Path == c.PATH_USER_DATA_STREAM {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == c.PATH_USER_DATA_STREAM:
+			created++
+			fmt.Fprintf(w, `{"listenKey":"key%d"}`, created)
+		case r.Method == http.MethodPut && r.URL.Path == c.PATH_USER_DATA_STREAM:
+			keptAlive = append(keptAlive, formValue(r, "listenKey"))
+			fmt.Fprint(w, `{}`)
+		case r.Method == http.MethodDelete && r.URL.Path == c.PATH_USER_DATA_STREAM:
+			closed = append(closed, formValue(r, "listenKey"))
+			fmt.Fprint(w, `{}`)
+		case strings.HasPrefix(r.URL.Path, "/ws/"):
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for _, e := range events[strings.TrimPrefix(r.URL.Path, "/ws/")] {
+					conn.WriteMessage(websocket.TextMessage, []byte(e))
+				}
+				conn.ReadMessage()
+			}()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cl := NewClient("test", nil, "apikey", "", server.URL, "ws"+strings.TrimPrefix(server.URL, "http"))
+	cl.Retry = testRetry
+	j, err := journal.Open(filepath.Join(t.TempDir(), "journal.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	cl.Journal = j
+	stream := cl.UserDataStream()
+	stream.keepAlive = 20 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	received := stream.Start(ctx)
+
+	want := []entity.UserDataEvent{
+		entity.ExecutionReport{
+			Event: c.EVENT_EXECUTION_REPORT, Time: 1, Symbol: "BTCFDUSD", ClientOrderId: "abc", Side: c.SIDE_BUY,
+			Type: c.ORDER_TYPE_MARKET, OrigQty: "0.001", Price: "0", ExecutionType: "TRADE", Status: "FILLED",
+			OrderId: 42, LastExecutedQty: "0.001", CumulativeFilledQty: "0.001", LastExecutedPrice: "40000",
+			Commission: "0", CommissionAsset: "BNB", TransactionTime: 2, TradeId: 7, Ignore: 99, IgnoreM: true,
+		},
+		entity.ExecutionReport{
+			Event: c.EVENT_EXECUTION_REPORT, Time: 2, Symbol: "BTCFDUSD", ClientOrderId: "def", Side: c.SIDE_SELL,
+			Type: c.ORDER_TYPE_LIMIT, TimeInForce: "GTC", OrigQty: "0.001", Price: "40000", ExecutionType: "TRADE_PREVENTION",
+			Status: "EXPIRED", OrderId: 43, TransactionTime: 2, TradeId: -1, PreventedMatchId: 3, Ignore: 100,
+			SelfTradePreventionMode: "EXPIRE_MAKER", PreventedQty: "0.001", LastPreventedQty: "0.001", TradeGroupId: 1, CounterOrderId: 42,
+		},
+		entity.ListenKeyExpiredEvent{Event: c.EVENT_LISTEN_KEY_EXPIRED, Time: 3, ListenKey: "key1"},
+		entity.AccountPositionEvent{Event: c.EVENT_ACCOUNT_POSITION, Time: 4, LastUpdateTime: 4,
+			Balances: []entity.AccountPositionBalance{{Asset: "BTC", Free: "0.001", Locked: "0"}}},
+		entity.BalanceUpdateEvent{Event: c.EVENT_BALANCE_UPDATE, Time: 6, Asset: "FDUSD", Delta: "-40", ClearTime: 6},
+	}
+	for i, w := range want {
+		got, ok := <-received
+		if !ok {
+			t.Fatalf("channel closed before event %d", i)
+		}
+		if !reflect.DeepEqual(got, w) {
+			t.Fatalf("event %d: got %#v, want %#v", i, got, w)
+		}
+	}
+
+	// The new listenKey is kept alive
+	for i := 0; ; i++ {
+		mu.Lock()
+		n := len(keptAlive)
+		last := ""
+		if n > 0 {
+			last = keptAlive[n-1]
+		}
+		mu.Unlock()
+		if last == "key2" {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("listen key not kept alive: %v", keptAlive)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	for range received {
+	}
+
+	// Only the fill is journaled, not the order expired by self-trade prevention
+	fills, err := j.Fills("", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 1 || fills[0].Trade.Id != 7 || fills[0].Trade.Price != "40000" || !fills[0].Trade.IsBuyer {
+		t.Errorf("journaled fills: got %+v", fills)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if created != 2 || !reflect.DeepEqual(closed, []string{"key2"}) {
+		t.Errorf("got %d listen keys created and %v closed, want 2 and [key2]", created, closed)
+	}
+}
+
+// formValue returns a parameter sent in the body, which http.Request.ParseForm doesn't read for DELETE requests.
+func formValue(r *http.Request, key string) string {
+	body, _ := io.ReadAll(r.Body)
+	values, _ := url.ParseQuery(string(body))
+	return values.Get(key)
+}
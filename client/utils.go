@@ -11,84 +11,190 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strings"
+	"time"
 
-	"github.com/michelemendel/binance/util"
+	c "github.com/michelemendel/binance/constant"
 )
 
-func (client *Client) Get(path, query string) []uint8 {
-	var url string
+func (client *Client) Get(path, query string) ([]uint8, error) {
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing query %q: %w", query, err)
+	}
+	securityType := c.SECURITY_TYPE_NONE
 	isSAPI, _ := regexp.MatchString("/sapi/", path)
-
 	if isSAPI {
-		ts := util.TimeNowInMillis()
-		signature := Signature(client.SecretKey, query, "", ts)
-		url = client.SAPIEndpoint(path, query, signature, ts)
+		securityType = c.SECURITY_TYPE_USER_DATA
+	}
+	return client.Do(http.MethodGet, path, params, securityType)
+}
+
+// Do makes a request to the REST API.
+// Parameters are sent in the query string for GET requests and in the body otherwise.
+// TRADE and USER_DATA requests are signed, all but NONE requests send the API key.
+// Responses with status 4xx or 5xx are returned as an *APIError.
+// GET requests that fail because of the network or the server are retried according to the retry policy.
+func (client *Client) Do(method, path string, params url.Values, securityType string) ([]uint8, error) {
+	attempts := 1
+	if method == http.MethodGet {
+		attempts = client.Retry.MaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := client.do(method, path, params, securityType)
+		if err == nil || attempt >= attempts || !isRetryable(err) {
+			return resp, err
+		}
+		delay := client.Retry.Backoff(attempt)
+		slog.Warn("retrying request", "method", method, "path", path, "attempt", attempt, "delay", delay, "error", err)
+		time.Sleep(delay)
+	}
+}
+
+func (client *Client) do(method, path string, params url.Values, securityType string) ([]uint8, error) {
+	// Wait before signing, so the timestamp is still within the recvWindow when the request is sent
+	err := client.RateLimiter.Wait(method, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var query, body string
+	if method == http.MethodGet {
+		query = params.Encode()
 	} else {
-		url = client.APIEndpoint(path, query)
+		body = params.Encode()
 	}
 
-	slog.Info("connection to server", "url", url)
+	if isSigned(securityType) {
+		if window := client.recvWindow(); window > 0 && !params.Has("recvWindow") {
+			recvWindow := fmt.Sprintf("recvWindow=%d", window)
+			if method == http.MethodGet {
+				query = joinParams(query, recvWindow)
+			} else {
+				body = joinParams(body, recvWindow)
+			}
+		}
+		ts := client.TimeSync.Now()
+		signature, err := SignatureWith(client.Signer, query, body, ts)
+		if err != nil {
+			return nil, fmt.Errorf("error signing request: %w", err)
+		}
+		signed := fmt.Sprintf("timestamp=%d&signature=%s", ts, signature)
+		if method == http.MethodGet {
+			query = joinParams(query, signed)
+		} else {
+			body = joinParams(body, signed)
+		}
+	}
+
+	host := client.Endpoints.Active()
+	url := endpoint(host, path, query)
+	slog.Info("connection to server", "method", method, "url", url, "securityType", securityType)
 
 	httpClient := &http.Client{Timeout: client.Timeout}
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
 	if err != nil {
-		slog.Error("error creating request", "error", err)
-		// return
+		return nil, fmt.Errorf("error creating request: %w", err)
 	}
 
-	req.Header.Set("X-MBX-APIKEY", client.APIKey)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if securityType != c.SECURITY_TYPE_NONE {
+		req.Header.Set("X-MBX-APIKEY", client.APIKey)
+	}
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		slog.Error("error making request", "url", url, "error", err)
-		return nil
+		client.Endpoints.Failover(host, err)
+		return nil, fmt.Errorf("error making request to %s: %w", path, err)
 	}
 	defer resp.Body.Close()
+	client.RateLimiter.Update(resp.Header, resp.StatusCode)
 
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		slog.Error("error reading response", "error", err)
-		return nil
+		return nil, fmt.Errorf("error reading response from %s: %w", path, err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := newAPIError(resp.StatusCode, respBody)
+		if resp.StatusCode >= http.StatusInternalServerError {
+			client.Endpoints.Failover(host, apiErr)
+		}
+		if apiErr.Code == c.ERROR_CODE_INVALID_TIMESTAMP {
+			// Resync for the next request
+			go client.TimeSync.Sync()
+		}
+		return nil, apiErr
 	}
-	return body
+	return respBody, nil
 }
 
-func (c *Client) APIEndpoint(path, query string) string {
-	base := fmt.Sprintf("%s%s", c.BaseAPI, path)
-
-	if query != "" {
-		base = base + fmt.Sprintf("?%s", query)
+// call makes the request and decodes the response into respInstance.
+// Requests with a WebSocket API method are sent over the WebSocket API if the client has one.
+func (client *Client) call(method, path string, params url.Values, securityType string, respInstance any) error {
+	if wsMethod, ok := wsAPIMethods[method+" "+path]; ok && client.WSAPI != nil {
+		return client.wsCall(wsMethod, method, path, params, securityType, respInstance)
 	}
+	resp, err := client.Do(method, path, params, securityType)
+	if err != nil {
+		return err
+	}
+	return decode(resp, respInstance)
+}
 
-	return base
+// recvWindow returns the recvWindow in milliseconds, at most MAX_RECV_WINDOW, or 0 if it's not set.
+func (client *Client) recvWindow() int64 {
+	window := client.RecvWindow
+	if window > c.MAX_RECV_WINDOW {
+		window = c.MAX_RECV_WINDOW
+	}
+	return window.Milliseconds()
 }
 
-func (c *Client) SAPIEndpoint(path, query, signature string, timestamp int64) string {
-	base := fmt.Sprintf("%s%s?timestamp=%v", c.BaseAPI, path, timestamp)
+func isSigned(securityType string) bool {
+	return securityType == c.SECURITY_TYPE_TRADE || securityType == c.SECURITY_TYPE_USER_DATA
+}
 
-	if signature != "" {
-		base = base + fmt.Sprintf("&signature=%s", signature)
+func joinParams(a, b string) string {
+	if a == "" {
+		return b
 	}
+	return a + "&" + b
+}
+
+// APIEndpoint returns the URL on the active host.
+func (c *Client) APIEndpoint(path, query string) string {
+	return endpoint(c.Endpoints.Active(), path, query)
+}
+
+func endpoint(host, path, query string) string {
+	base := fmt.Sprintf("%s%s", host, path)
 
 	if query != "" {
-		base = base + fmt.Sprintf("&%s", query)
+		base = base + fmt.Sprintf("?%s", query)
 	}
 
 	return base
 }
 
+// Signature signs with HMAC-SHA256, see SignatureWith.
 func Signature(secretKey, query, body string, timestamp int64) string {
-	var payload string
+	signature, _ := SignatureWith(HMACSigner{SecretKey: secretKey}, query, body, timestamp)
+	return signature
+}
 
-	if query != "" {
-		payload = query + body + fmt.Sprintf("&timestamp=%d", timestamp)
-	} else {
-		payload = fmt.Sprintf("timestamp=%d", timestamp)
-	}
+// SignatureWith signs the query and body with the timestamp, and URL encodes the signature.
+func SignatureWith(signer Signer, query, body string, timestamp int64) (string, error) {
+	// totalParams is the query string concatenated with the body, the timestamp is last
+	payload := joinParams(query+body, fmt.Sprintf("timestamp=%d", timestamp))
 
-	signed := HMACSign(secretKey, payload)
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
 	urlEncoded := urlEncode(signed)
-	return urlEncoded
+	return urlEncoded, nil
 }
 
 // todo: Need this, do we?
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	c "github.com/michelemendel/binance/constant"
+)
+
+func TestDo(t *testing.T) {
+	server, cl := newFakeBinance(t)
+	order := url.Values{}
+	order.Set("symbol", "BTCFDUSD")
+	order.Set("side", c.SIDE_BUY)
+	order.Set("type", c.ORDER_TYPE_LIMIT)
+	order.Set("timeInForce", c.TIME_IN_FORCE_GTC)
+	order.Set("quantity", "0.001")
+	order.Set("price", "30000")
+	cancel := url.Values{}
+	cancel.Set("symbol", "BTCFDUSD")
+	cancel.Set("orderId", "1")
+	account := url.Values{}
+	account.Set("omitZeroBalances", "true")
+
+	tests := []struct {
+		name         string
+		method, path string
+		params       url.Values
+		securityType string
+		apiKey       bool
+		signed       string // Where the timestamp and signature are sent: query, body or none
+	}{
+		{"none", http.MethodGet, c.PATH_PING, nil, c.SECURITY_TYPE_NONE, false, "none"},
+		{"market data", http.MethodGet, c.PATH_PING, nil, c.SECURITY_TYPE_MARKET_DATA, true, "none"},
+		{"user stream", http.MethodGet, c.PATH_PING, nil, c.SECURITY_TYPE_USER_STREAM, true, "none"},
+		{"user data", http.MethodGet, c.PATH_ACCOUNT, account, c.SECURITY_TYPE_USER_DATA, true, "query"},
+		{"user data, no params", http.MethodGet, c.PATH_ACCOUNT, nil, c.SECURITY_TYPE_USER_DATA, true, "query"},
+		{"trade, POST", http.MethodPost, c.PATH_ORDER, order, c.SECURITY_TYPE_TRADE, true, "body"},
+		{"trade, DELETE", http.MethodDelete, c.PATH_ORDER, cancel, c.SECURITY_TYPE_TRADE, true, "body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := len(server.Requests(tt.method, tt.path))
+			// The fake server rejects signed requests with an invalid signature
+			_, err := cl.Do(tt.method, tt.path, tt.params, tt.securityType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			requests := server.Requests(tt.method, tt.path)[sent:]
+			if len(requests) != 1 {
+				t.Fatalf("requests: got %d, want 1", len(requests))
+			}
+			req := requests[0]
+
+			if got := req.APIKey != ""; got != tt.apiKey {
+				t.Errorf("API key sent: got %v, want %v", got, tt.apiKey)
+			}
+			for k := range tt.params {
+				if req.Params.Get(k) != tt.params.Get(k) {
+					t.Errorf("param %s: got %q, want %q", k, req.Params.Get(k), tt.params.Get(k))
+				}
+			}
+			signedIn := map[string]string{"query": req.Query, "body": req.Body}
+			for where, sent := range signedIn {
+				hasTimestamp := strings.Contains(sent, "timestamp=")
+				hasSignature := strings.Contains(sent, "&signature=")
+				if hasTimestamp != (where == tt.signed) || hasSignature != (where == tt.signed) {
+					t.Errorf("%s %q: got timestamp %v and signature %v, want them in the %s", where, sent, hasTimestamp, hasSignature, tt.signed)
+				}
+			}
+			// Parameters go in the query string for GET requests and in the body otherwise
+			if tt.method == http.MethodGet && req.Body != "" {
+				t.Errorf("GET body: got %q", req.Body)
+			}
+			if tt.method != http.MethodGet && req.Query != "" {
+				t.Errorf("%s query: got %q", tt.method, req.Query)
+			}
+		})
+	}
+
+	// Signed with another secret key
+	other := NewClient("test", nil, "apikey", strings.Repeat("x", len(secretKey)), server.URL, "")
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		path, params := c.PATH_ACCOUNT, url.Values(nil)
+		if method == http.MethodPost {
+			path, params = c.PATH_ORDER, order
+		}
+		_, err := other.Do(method, path, params, c.SECURITY_TYPE_TRADE)
+		if !IsAPIError(err, c.ERROR_CODE_INVALID_SIGNATURE) {
+			t.Errorf("%s with another secret key: got %v, want code %d", method, err, c.ERROR_CODE_INVALID_SIGNATURE)
+		}
+	}
+}
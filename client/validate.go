@@ -0,0 +1,175 @@
+package client
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+	"github.com/michelemendel/binance/util"
+)
+
+// Pre-trade validation against the symbol filters, so we don't spend rate limit on orders Binance will reject.
+// https://binance-docs.github.io/apidocs/spot/en/#filters
+
+type FilterFailure struct {
+	Filter string
+	Reason string
+}
+
+// OrderFilterError is returned when an order violates one or more symbol filters.
+type OrderFilterError struct {
+	Symbol   string
+	Failures []FilterFailure
+}
+
+func (e *OrderFilterError) Error() string {
+	reasons := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		reasons[i] = fmt.Sprintf("%s: %s", f.Filter, f.Reason)
+	}
+	return fmt.Sprintf("order for %s rejected by filters: %s", e.Symbol, strings.Join(reasons, "; "))
+}
+
+func (e *OrderFilterError) add(filter, format string, args ...any) {
+	e.Failures = append(e.Failures, FilterFailure{Filter: filter, Reason: fmt.Sprintf(format, args...)})
+}
+
+// ValidateOrder rounds quantities down to the step size and prices to the tick size,
+// and checks the result against the symbol filters.
+// It returns the adjusted request, or an *OrderFilterError listing the filters that failed.
+func ValidateOrder(symbol entity.SymbolInfo, req entity.OrderRequest) (entity.OrderRequest, error) {
+	ferr := &OrderFilterError{Symbol: req.Symbol}
+	isMarket := req.Type == c.ORDER_TYPE_MARKET || req.Type == c.ORDER_TYPE_STOP_LOSS || req.Type == c.ORDER_TYPE_TAKE_PROFIT
+
+	if symbol.Status != "" && symbol.Status != c.SYMBOL_STATUS_TRADING {
+		ferr.add("STATUS", "symbol status is %s", symbol.Status)
+	}
+	if len(symbol.OrderTypes) > 0 && !symbol.AllowsOrderType(req.Type) {
+		ferr.add("ORDER_TYPES", "%s orders are not allowed, allowed are %v", req.Type, symbol.OrderTypes)
+	}
+	if req.QuoteOrderQty > 0 && !symbol.QuoteOrderQtyMarketAllowed {
+		ferr.add("ORDER_TYPES", "quoteOrderQty is not allowed for market orders")
+	}
+	if req.IcebergQty > 0 && !symbol.IcebergAllowed {
+		ferr.add("ORDER_TYPES", "iceberg orders are not allowed")
+	}
+
+	if f := symbol.Filter(c.FILTER_PRICE); f != nil {
+		req.Price = checkPrice(ferr, f, "price", req.Price)
+		req.StopPrice = checkPrice(ferr, f, "stopPrice", req.StopPrice)
+	}
+
+	lotFilters := []string{c.FILTER_LOT_SIZE}
+	if isMarket {
+		lotFilters = append(lotFilters, c.FILTER_MARKET_LOT_SIZE)
+	}
+	for _, filterType := range lotFilters {
+		f := symbol.Filter(filterType)
+		if f == nil || req.Quantity <= 0 {
+			continue
+		}
+		req.Quantity = RoundDown(req.Quantity, f.StepSize)
+		req.IcebergQty = RoundDown(req.IcebergQty, f.StepSize)
+		minQty := util.String2Float(f.MinQty)
+		maxQty := util.String2Float(f.MaxQty)
+		if req.Quantity <= 0 {
+			ferr.add(filterType, "quantity rounds down to 0 with stepSize %s", f.StepSize)
+		} else if req.Quantity < minQty {
+			ferr.add(filterType, "quantity %v is below minQty %s", req.Quantity, f.MinQty)
+		}
+		if maxQty > 0 && req.Quantity > maxQty {
+			ferr.add(filterType, "quantity %v is above maxQty %s", req.Quantity, f.MaxQty)
+		}
+	}
+
+	if f := symbol.Filter(c.FILTER_ICEBERG_PARTS); f != nil && req.IcebergQty > 0 && f.Limit > 0 {
+		parts := int(math.Ceil(req.Quantity / req.IcebergQty))
+		if parts > f.Limit {
+			ferr.add(c.FILTER_ICEBERG_PARTS, "order splits into %d parts, limit is %d", parts, f.Limit)
+		}
+	}
+
+	// The notional of market orders is only known up front when they are placed with quoteOrderQty.
+	notional := 0.0
+	switch {
+	case req.QuoteOrderQty > 0:
+		notional = req.QuoteOrderQty
+	case req.Price > 0:
+		notional = req.Price * req.Quantity
+	case req.StopPrice > 0:
+		notional = req.StopPrice * req.Quantity
+	}
+	if notional > 0 {
+		if f := symbol.Filter(c.FILTER_MIN_NOTIONAL); f != nil && (!isMarket || f.ApplyToMarket) {
+			if minNotional := util.String2Float(f.MinNotional); notional < minNotional {
+				ferr.add(c.FILTER_MIN_NOTIONAL, "notional %v is below minNotional %s", notional, f.MinNotional)
+			}
+		}
+		if f := symbol.Filter(c.FILTER_NOTIONAL); f != nil {
+			if minNotional := util.String2Float(f.MinNotional); (!isMarket || f.ApplyMinToMarket) && notional < minNotional {
+				ferr.add(c.FILTER_NOTIONAL, "notional %v is below minNotional %s", notional, f.MinNotional)
+			}
+			if maxNotional := util.String2Float(f.MaxNotional); (!isMarket || f.ApplyMaxToMarket) && maxNotional > 0 && notional > maxNotional {
+				ferr.add(c.FILTER_NOTIONAL, "notional %v is above maxNotional %s", notional, f.MaxNotional)
+			}
+		}
+	}
+
+	if len(ferr.Failures) > 0 {
+		return req, ferr
+	}
+	return req, nil
+}
+
+func checkPrice(ferr *OrderFilterError, f *entity.SymbolFilter, name string, price float64) float64 {
+	if price <= 0 {
+		return price
+	}
+	price = RoundNearest(price, f.TickSize)
+	minPrice := util.String2Float(f.MinPrice)
+	maxPrice := util.String2Float(f.MaxPrice)
+	if minPrice > 0 && price < minPrice {
+		ferr.add(c.FILTER_PRICE, "%s %v is below minPrice %s", name, price, f.MinPrice)
+	}
+	if maxPrice > 0 && price > maxPrice {
+		ferr.add(c.FILTER_PRICE, "%s %v is above maxPrice %s", name, price, f.MaxPrice)
+	}
+	return price
+}
+
+// RoundDown rounds x down to a multiple of step, e.g. step "0.00100000".
+// A zero or empty step leaves x unchanged.
+func RoundDown(x float64, step string) float64 {
+	return roundToStep(x, step, math.Floor)
+}
+
+// RoundNearest rounds x to the nearest multiple of step.
+func RoundNearest(x float64, step string) float64 {
+	return roundToStep(x, step, math.Round)
+}
+
+func roundToStep(x float64, step string, round func(float64) float64) float64 {
+	if x == 0 || step == "" {
+		return x
+	}
+	s := util.String2Float(step)
+	if s <= 0 {
+		return x
+	}
+	// The small epsilon keeps e.g. 0.3/0.1 = 2.9999999999999996 from being floored to 2
+	n := round(x/s + 1e-9)
+	p := math.Pow10(stepDecimals(step))
+	return math.Round(n*s*p) / p
+}
+
+// stepDecimals returns the number of significant decimals in a step like "0.01000000".
+func stepDecimals(step string) int {
+	trimmed := strings.TrimRight(step, "0")
+	i := strings.IndexByte(trimmed, '.')
+	if i < 0 {
+		return 0
+	}
+	return len(trimmed) - i - 1
+}
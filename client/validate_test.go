@@ -0,0 +1,160 @@
+package client
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/michelemendel/binance/entity"
+)
+
+// Filters as returned by exchangeInfo for BTCFDUSD
+var btcfdusd = entity.SymbolInfo{
+	Symbol:                     "BTCFDUSD",
+	Status:                     "TRADING",
+	OrderTypes:                 []string{"LIMIT", "LIMIT_MAKER", "MARKET", "STOP_LOSS_LIMIT", "TAKE_PROFIT_LIMIT"},
+	IcebergAllowed:             true,
+	QuoteOrderQtyMarketAllowed: true,
+	Filters: []entity.SymbolFilter{
+		{FilterType: "PRICE_FILTER", MinPrice: "0.01000000", MaxPrice: "1000000.00000000", TickSize: "0.01000000"},
+		{FilterType: "LOT_SIZE", MinQty: "0.00001000", MaxQty: "9000.00000000", StepSize: "0.00001000"},
+		{FilterType: "ICEBERG_PARTS", Limit: 10},
+		{FilterType: "MARKET_LOT_SIZE", MinQty: "0.00000000", MaxQty: "56.00000000", StepSize: "0.00000000"},
+		{FilterType: "NOTIONAL", MinNotional: "5.00000000", ApplyMinToMarket: true, MaxNotional: "9000000.00000000", ApplyMaxToMarket: false},
+	},
+}
+
+func TestValidateOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      entity.OrderRequest
+		expected entity.OrderRequest
+		failures []string
+	}{
+		{name: "RoundsQuantityAndPrice",
+			req:      entity.OrderRequest{Symbol: "BTCFDUSD", Type: "LIMIT", TimeInForce: "GTC", Quantity: 0.0013678, Price: 43210.456},
+			expected: entity.OrderRequest{Symbol: "BTCFDUSD", Type: "LIMIT", TimeInForce: "GTC", Quantity: 0.00136, Price: 43210.46},
+		},
+		{name: "MarketQuoteOrderQty",
+			req:      entity.OrderRequest{Symbol: "BTCFDUSD", Type: "MARKET", QuoteOrderQty: 100},
+			expected: entity.OrderRequest{Symbol: "BTCFDUSD", Type: "MARKET", QuoteOrderQty: 100},
+		},
+		{name: "BelowMinNotional",
+			req:      entity.OrderRequest{Symbol: "BTCFDUSD", Type: "MARKET", QuoteOrderQty: 4},
+			failures: []string{"NOTIONAL"},
+		},
+		{name: "QuantityRoundsToZero",
+			req:      entity.OrderRequest{Symbol: "BTCFDUSD", Type: "LIMIT", TimeInForce: "GTC", Quantity: 0.000001, Price: 43000},
+			failures: []string{"LOT_SIZE"},
+		},
+		{name: "AboveMaxQty",
+			req:      entity.OrderRequest{Symbol: "BTCFDUSD", Type: "MARKET", Quantity: 60},
+			failures: []string{"MARKET_LOT_SIZE"},
+		},
+		{name: "OrderTypeNotAllowed",
+			req:      entity.OrderRequest{Symbol: "BTCFDUSD", Type: "STOP_LOSS", Quantity: 0.001, StopPrice: 40000},
+			failures: []string{"ORDER_TYPES"},
+		},
+		{name: "TooManyIcebergParts",
+			req:      entity.OrderRequest{Symbol: "BTCFDUSD", Type: "LIMIT", TimeInForce: "GTC", Quantity: 0.011, IcebergQty: 0.001, Price: 43000},
+			failures: []string{"ICEBERG_PARTS"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ValidateOrder(btcfdusd, tt.req)
+			if len(tt.failures) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateOrder() error = %v", err)
+				}
+				if actual != tt.expected {
+					t.Errorf("ValidateOrder() = %+v, want %+v", actual, tt.expected)
+				}
+				return
+			}
+
+			var ferr *OrderFilterError
+			if !errors.As(err, &ferr) {
+				t.Fatalf("ValidateOrder() error = %v, want *OrderFilterError", err)
+			}
+			if len(ferr.Failures) != len(tt.failures) {
+				t.Fatalf("ValidateOrder() failures = %+v, want %v", ferr.Failures, tt.failures)
+			}
+			for i, f := range ferr.Failures {
+				if f.Filter != tt.failures[i] {
+					t.Errorf("ValidateOrder() failure %d = %s, want %s", i, f.Filter, tt.failures[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckOrderRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  entity.OrderRequest
+		err  string // Part of the error, empty if none
+	}{
+		{name: "Market", req: entity.OrderRequest{Type: "MARKET", Quantity: 0.001}},
+		{name: "MarketQuoteOrderQty", req: entity.OrderRequest{Type: "MARKET", QuoteOrderQty: 100}},
+		{name: "MarketNoQuantity", req: entity.OrderRequest{Type: "MARKET"}, err: "quantity must be greater than 0"},
+		{name: "MarketTimeInForce", req: entity.OrderRequest{Type: "MARKET", Quantity: 0.001, TimeInForce: "GTC"}, err: "timeInForce is not allowed"},
+		{name: "NoSide", req: entity.OrderRequest{Type: "MARKET", Quantity: 0.001, Side: "-"}, err: "side must be"},
+		{name: "UnknownType", req: entity.OrderRequest{Type: "STOP", Quantity: 0.001}, err: "unknown order type"},
+
+		{name: "Limit", req: entity.OrderRequest{Type: "LIMIT", Quantity: 0.001, Price: 43000, TimeInForce: "GTC"}},
+		{name: "LimitNoPrice", req: entity.OrderRequest{Type: "LIMIT", Quantity: 0.001, TimeInForce: "GTC"}, err: "price must be greater than 0"},
+		{name: "LimitNoTimeInForce", req: entity.OrderRequest{Type: "LIMIT", Quantity: 0.001, Price: 43000}, err: "timeInForce must be"},
+		{name: "LimitBadTimeInForce", req: entity.OrderRequest{Type: "LIMIT", Quantity: 0.001, Price: 43000, TimeInForce: "GTX"}, err: "timeInForce must be"},
+		{name: "LimitNoQuantity", req: entity.OrderRequest{Type: "LIMIT", Price: 43000, TimeInForce: "GTC"}, err: "quantity must be greater than 0"},
+		{name: "LimitQuoteOrderQty", req: entity.OrderRequest{Type: "LIMIT", Quantity: 0.001, QuoteOrderQty: 100, Price: 43000, TimeInForce: "GTC"}, err: "quoteOrderQuantity is only allowed"},
+
+		{name: "LimitMaker", req: entity.OrderRequest{Type: "LIMIT_MAKER", Quantity: 0.001, Price: 43000}},
+		{name: "LimitMakerNoPrice", req: entity.OrderRequest{Type: "LIMIT_MAKER", Quantity: 0.001}, err: "price must be greater than 0"},
+		{name: "LimitMakerTimeInForce", req: entity.OrderRequest{Type: "LIMIT_MAKER", Quantity: 0.001, Price: 43000, TimeInForce: "GTC"}, err: "timeInForce is not allowed"},
+
+		{name: "StopLoss", req: entity.OrderRequest{Type: "STOP_LOSS", Quantity: 0.001, StopPrice: 40000}},
+		{name: "StopLossNoStopPrice", req: entity.OrderRequest{Type: "STOP_LOSS", Quantity: 0.001}, err: "stopPrice must be greater than 0"},
+		{name: "StopLossTimeInForce", req: entity.OrderRequest{Type: "STOP_LOSS", Quantity: 0.001, StopPrice: 40000, TimeInForce: "GTC"}, err: "timeInForce is not allowed"},
+		{name: "StopLossLimit", req: entity.OrderRequest{Type: "STOP_LOSS_LIMIT", Quantity: 0.001, Price: 39900, StopPrice: 40000, TimeInForce: "GTC"}},
+		{name: "StopLossLimitNoPrice", req: entity.OrderRequest{Type: "STOP_LOSS_LIMIT", Quantity: 0.001, StopPrice: 40000, TimeInForce: "GTC"}, err: "price must be greater than 0"},
+		{name: "StopLossLimitNoStopPrice", req: entity.OrderRequest{Type: "STOP_LOSS_LIMIT", Quantity: 0.001, Price: 39900, TimeInForce: "GTC"}, err: "stopPrice must be greater than 0"},
+		{name: "StopLossLimitNoTimeInForce", req: entity.OrderRequest{Type: "STOP_LOSS_LIMIT", Quantity: 0.001, Price: 39900, StopPrice: 40000}, err: "timeInForce must be"},
+
+		{name: "TakeProfit", req: entity.OrderRequest{Type: "TAKE_PROFIT", Quantity: 0.001, StopPrice: 50000}},
+		{name: "TakeProfitNoStopPrice", req: entity.OrderRequest{Type: "TAKE_PROFIT", Quantity: 0.001}, err: "stopPrice must be greater than 0"},
+		{name: "TakeProfitLimit", req: entity.OrderRequest{Type: "TAKE_PROFIT_LIMIT", Quantity: 0.001, Price: 50100, StopPrice: 50000, TimeInForce: "IOC"}},
+		{name: "TakeProfitLimitNoPrice", req: entity.OrderRequest{Type: "TAKE_PROFIT_LIMIT", Quantity: 0.001, StopPrice: 50000, TimeInForce: "GTC"}, err: "price must be greater than 0"},
+		{name: "TakeProfitLimitNoStopPrice", req: entity.OrderRequest{Type: "TAKE_PROFIT_LIMIT", Quantity: 0.001, Price: 50100, TimeInForce: "GTC"}, err: "stopPrice must be greater than 0"},
+		{name: "TakeProfitLimitNoTimeInForce", req: entity.OrderRequest{Type: "TAKE_PROFIT_LIMIT", Quantity: 0.001, Price: 50100, StopPrice: 50000}, err: "timeInForce must be"},
+
+		{name: "IcebergLimitGTC", req: entity.OrderRequest{Type: "LIMIT", Quantity: 0.01, IcebergQty: 0.002, Price: 43000, TimeInForce: "GTC"}},
+		{name: "IcebergLimitIOC", req: entity.OrderRequest{Type: "LIMIT", Quantity: 0.01, IcebergQty: 0.002, Price: 43000, TimeInForce: "IOC"}, err: "icebergQty requires timeInForce GTC"},
+		{name: "IcebergStopLossLimitFOK", req: entity.OrderRequest{Type: "STOP_LOSS_LIMIT", Quantity: 0.01, IcebergQty: 0.002, Price: 39900, StopPrice: 40000, TimeInForce: "FOK"}, err: "icebergQty requires timeInForce GTC"},
+		{name: "IcebergTakeProfitLimitGTC", req: entity.OrderRequest{Type: "TAKE_PROFIT_LIMIT", Quantity: 0.01, IcebergQty: 0.002, Price: 50100, StopPrice: 50000, TimeInForce: "GTC"}},
+		{name: "IcebergLimitMaker", req: entity.OrderRequest{Type: "LIMIT_MAKER", Quantity: 0.01, IcebergQty: 0.002, Price: 43000}},
+		{name: "IcebergMarket", req: entity.OrderRequest{Type: "MARKET", Quantity: 0.01, IcebergQty: 0.002}, err: "icebergQty is not allowed"},
+		{name: "IcebergNotLessThanQuantity", req: entity.OrderRequest{Type: "LIMIT", Quantity: 0.01, IcebergQty: 0.01, Price: 43000, TimeInForce: "GTC"}, err: "icebergQty must be less than quantity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			req.Symbol = "BTCFDUSD"
+			if req.Side == "" {
+				req.Side = "BUY"
+			}
+			err := checkOrderRequest(req)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("checkOrderRequest() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("checkOrderRequest() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
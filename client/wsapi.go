@@ -0,0 +1,373 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	c "github.com/michelemendel/binance/constant"
+)
+
+// errWSAPINoResponse is wrapped by the errors of requests that got no response, because the connection was lost
+// or the request timed out. Like a network error on REST, the request may still have been executed.
+var errWSAPINoResponse = errors.New("no response from the WebSocket API")
+
+// WebSocket API methods of the REST endpoints, keyed by method and path like endpointWeights.
+// https://binance-docs.github.io/apidocs/websocket_api/en/
+var wsAPIMethods = map[string]string{
+	"GET " + c.PATH_DEPTH:               "depth",
+	"GET " + c.PATH_KLINES:              "klines",
+	"POST " + c.PATH_ORDER:              "order.place",
+	"POST " + c.PATH_ORDER_TEST:         "order.test",
+	"GET " + c.PATH_ORDER:               "order.status",
+	"DELETE " + c.PATH_ORDER:            "order.cancel",
+	"POST " + c.PATH_CANCEL_REPLACE:     "order.cancelReplace",
+	"POST " + c.PATH_ORDER_OCO:          "orderList.place",
+	"GET " + c.PATH_OPEN_ORDERS:         "openOrders.status",
+	"DELETE " + c.PATH_OPEN_ORDERS:      "openOrders.cancelAll",
+	"GET " + c.PATH_ALL_ORDERS:          "allOrders",
+	"GET " + c.PATH_MY_TRADES:           "myTrades",
+	"GET " + c.PATH_ACCOUNT:             "account.status",
+	"POST " + c.PATH_USER_DATA_STREAM:   "userDataStream.start",
+	"PUT " + c.PATH_USER_DATA_STREAM:    "userDataStream.ping",
+	"DELETE " + c.PATH_USER_DATA_STREAM: "userDataStream.stop",
+}
+
+// Parameters that are integers or booleans in the WebSocket API. All others are sent as strings.
+var wsAPIIntParams = map[string]bool{
+	"limit": true, "orderId": true, "orderListId": true, "cancelOrderId": true, "fromId": true,
+	"startTime": true, "endTime": true, "trailingDelta": true, "strategyId": true, "strategyType": true,
+	"recvWindow": true, "timestamp": true,
+}
+var wsAPIBoolParams = map[string]bool{
+	"omitZeroBalances": true, "computeCommissionRates": true,
+}
+
+type wsAPIRequest struct {
+	Id     int64          `json:"id"`
+	Method string         `json:"method"`
+	Params map[string]any `json:"params,omitempty"`
+}
+
+type wsAPIResponse struct {
+	Id         *int64          `json:"id"`
+	Status     int             `json:"status"`
+	Result     json.RawMessage `json:"result"`
+	Error      *APIError       `json:"error"`
+	RateLimits []struct {
+		RateLimitType string `json:"rateLimitType"`
+		Interval      string `json:"interval"`
+		IntervalNum   int    `json:"intervalNum"`
+		Count         int    `json:"count"`
+	} `json:"rateLimits"`
+	err error // Set if the connection was lost
+}
+
+// WSAPI sends requests over the WebSocket API, which has lower latency than the REST API.
+// Requests are matched to their responses by id. The connection is opened by the first request,
+// and opened again by the next request after it's lost, e.g. when the server closes it at 24 hours.
+// With an Ed25519 key the session is logged on when connecting, so signed requests need no signature.
+// https://binance-docs.github.io/apidocs/websocket_api/en/
+type WSAPI struct {
+	client *Client // For the API key, the signer and the time of the session logon
+	url    string
+
+	connMu sync.Mutex // Held while connecting
+
+	mu       sync.Mutex
+	conn     *websocket.Conn // Nil while disconnected
+	loggedOn bool
+	nextId   int64
+	pending  map[int64]chan wsAPIResponse
+}
+
+// NewWSAPI returns a WebSocket API at baseWS, e.g. BASE_WS_PROD_3, that signs requests with the client's keys.
+// Set it as the client's WSAPI to send the client's requests over it.
+func NewWSAPI(client *Client, baseWS string) *WSAPI {
+	return &WSAPI{
+		client:  client,
+		url:     baseWS + c.PATH_WS_API,
+		pending: map[int64]chan wsAPIResponse{},
+	}
+}
+
+// Request sends a request, e.g. "session.status", and decodes its result into result, if not nil.
+// The params are sent as they are, so signed requests need apiKey, timestamp and signature unless logged on.
+// Errors returned by Binance are *APIError, as on REST.
+func (w *WSAPI) Request(ctx context.Context, method string, params map[string]any, result any) error {
+	conn, _, err := w.connection(ctx)
+	if err != nil {
+		return err
+	}
+	return w.request(ctx, conn, method, params, result)
+}
+
+// LoggedOn tells if the session is logged on.
+func (w *WSAPI) LoggedOn() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.loggedOn
+}
+
+// Close closes the connection. A new one is opened by the next request.
+func (w *WSAPI) Close() error {
+	w.mu.Lock()
+	conn := w.conn
+	w.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
+// connection returns the connection, and if the session is logged on, connecting and logging on first if needed.
+func (w *WSAPI) connection(ctx context.Context) (*websocket.Conn, bool, error) {
+	w.connMu.Lock()
+	defer w.connMu.Unlock()
+	w.mu.Lock()
+	conn, loggedOn := w.conn, w.loggedOn
+	w.mu.Unlock()
+	if conn != nil {
+		return conn, loggedOn, nil
+	}
+
+	dialer := websocket.Dialer{HandshakeTimeout: w.client.Timeout}
+	conn, _, err := dialer.DialContext(ctx, w.url, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error connecting to %s: %w", w.url, err)
+	}
+	w.mu.Lock()
+	w.conn = conn
+	w.mu.Unlock()
+	go w.read(conn)
+	slog.Info("connected WebSocket API", "url", w.url)
+
+	if _, ok := w.client.Signer.(*Ed25519Signer); ok {
+		err := w.logon(ctx, conn)
+		if err != nil {
+			// Requests are signed one by one instead
+			slog.Error("error logging on to the WebSocket API", "error", err)
+			return conn, false, nil
+		}
+		return conn, true, nil
+	}
+	return conn, false, nil
+}
+
+// Log in with API key (SIGNED)
+// https://binance-docs.github.io/apidocs/websocket_api/en/#log-in-with-api-key-signed
+func (w *WSAPI) logon(ctx context.Context, conn *websocket.Conn) error {
+	params := map[string]any{
+		"apiKey":    w.client.APIKey,
+		"timestamp": w.client.TimeSync.Now(),
+	}
+	signature, err := w.client.Signer.Sign(wsAPIPayload(params))
+	if err != nil {
+		return fmt.Errorf("error signing logon: %w", err)
+	}
+	params["signature"] = signature
+	err = w.request(ctx, conn, c.WS_API_SESSION_LOGON, params, nil)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	if w.conn == conn {
+		w.loggedOn = true
+	}
+	w.mu.Unlock()
+	slog.Info("logged on to the WebSocket API")
+	return nil
+}
+
+// request sends a request on the connection and waits for the response, at most the client's timeout.
+func (w *WSAPI) request(ctx context.Context, conn *websocket.Conn, method string, params map[string]any, result any) error {
+	w.mu.Lock()
+	w.nextId++
+	id := w.nextId
+	resp := make(chan wsAPIResponse, 1)
+	w.pending[id] = resp
+	err := conn.SetWriteDeadline(time.Now().Add(w.client.Timeout))
+	if err == nil {
+		err = conn.WriteJSON(wsAPIRequest{Id: id, Method: method, Params: params})
+	}
+	w.mu.Unlock()
+
+	defer func() {
+		w.mu.Lock()
+		delete(w.pending, id)
+		w.mu.Unlock()
+	}()
+	if err != nil {
+		return fmt.Errorf("error sending %s: %w", method, err)
+	}
+
+	timeout := time.NewTimer(w.client.Timeout)
+	defer timeout.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout.C:
+		return fmt.Errorf("%s %d timed out after %v: %w", method, id, w.client.Timeout, errWSAPINoResponse)
+	case r := <-resp:
+		if r.err != nil {
+			return fmt.Errorf("%s %d: %v: %w", method, id, r.err, errWSAPINoResponse)
+		}
+		w.updateRateLimits(r)
+		if r.Error != nil {
+			r.Error.StatusCode = r.Status
+			return r.Error
+		}
+		if result != nil {
+			return json.Unmarshal(r.Result, result)
+		}
+		return nil
+	}
+}
+
+// read passes the responses to the requests waiting for them until the connection fails or is closed.
+// The requests still waiting then fail, and the next request opens a new connection.
+func (w *WSAPI) read(conn *websocket.Conn) {
+	handlePings(conn)
+	var err error
+	for {
+		err = conn.SetReadDeadline(time.Now().Add(c.WS_READ_TIMEOUT))
+		if err != nil {
+			break
+		}
+		var data []byte
+		_, data, err = conn.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		var r wsAPIResponse
+		decodeErr := json.Unmarshal(data, &r)
+		if decodeErr != nil || r.Id == nil {
+			slog.Error("unexpected WebSocket API message", "message", string(data), "error", decodeErr)
+			continue
+		}
+		// Responses to requests that timed out are dropped
+		w.mu.Lock()
+		if resp, ok := w.pending[*r.Id]; ok {
+			select {
+			case resp <- r:
+			default:
+			}
+		}
+		w.mu.Unlock()
+	}
+
+	slog.Warn("WebSocket API disconnected", "error", err)
+	conn.Close()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == conn {
+		w.conn = nil
+		w.loggedOn = false
+	}
+	for _, resp := range w.pending {
+		select {
+		case resp <- wsAPIResponse{err: err}:
+		default:
+		}
+	}
+}
+
+// updateRateLimits passes the usage reported in the response to the rate limiter, as if it came in REST headers,
+// since the WebSocket API and the REST API share the limits.
+func (w *WSAPI) updateRateLimits(r wsAPIResponse) {
+	header := http.Header{}
+	for _, l := range r.RateLimits {
+		switch {
+		case l.RateLimitType == c.RATE_LIMIT_REQUEST_WEIGHT && l.Interval == "MINUTE" && l.IntervalNum == 1:
+			header.Set(c.HEADER_USED_WEIGHT_1M, strconv.Itoa(l.Count))
+		case l.RateLimitType == c.RATE_LIMIT_ORDERS && l.Interval == "SECOND" && l.IntervalNum == 10:
+			header.Set(c.HEADER_ORDER_COUNT_10S, strconv.Itoa(l.Count))
+		case l.RateLimitType == c.RATE_LIMIT_ORDERS && l.Interval == "DAY" && l.IntervalNum == 1:
+			header.Set(c.HEADER_ORDER_COUNT_1D, strconv.Itoa(l.Count))
+		}
+	}
+	w.client.RateLimiter.Update(header, r.Status)
+}
+
+// wsCall makes a REST request over the WebSocket API, see call.
+// Signed requests get a timestamp, and unless the session is logged on, the API key and a signature.
+func (client *Client) wsCall(wsMethod, method, path string, params url.Values, securityType string, respInstance any) error {
+	ctx := context.Background()
+	conn, loggedOn, err := client.WSAPI.connection(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Wait before signing, so the timestamp is still within the recvWindow when the request is sent
+	err = client.RateLimiter.Wait(method, path)
+	if err != nil {
+		return err
+	}
+
+	ps := map[string]any{}
+	for k := range params {
+		ps[k] = wsAPIParam(k, params.Get(k))
+	}
+	switch {
+	case securityType == c.SECURITY_TYPE_USER_STREAM:
+		ps["apiKey"] = client.APIKey
+	case isSigned(securityType):
+		if window := client.recvWindow(); window > 0 && !params.Has("recvWindow") {
+			ps["recvWindow"] = window
+		}
+		ps["timestamp"] = client.TimeSync.Now()
+		if !loggedOn {
+			ps["apiKey"] = client.APIKey
+			signature, err := client.Signer.Sign(wsAPIPayload(ps))
+			if err != nil {
+				return fmt.Errorf("error signing request: %w", err)
+			}
+			ps["signature"] = signature
+		}
+	}
+
+	slog.Info("request to WebSocket API", "method", wsMethod, "securityType", securityType)
+	return client.WSAPI.request(ctx, conn, wsMethod, ps, respInstance)
+}
+
+func wsAPIParam(key, value string) any {
+	if wsAPIIntParams[key] {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err == nil {
+			return n
+		}
+	}
+	if wsAPIBoolParams[key] {
+		b, err := strconv.ParseBool(value)
+		if err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// wsAPIPayload is the payload signed: the parameters sorted by name, as name=value separated by &.
+// https://binance-docs.github.io/apidocs/websocket_api/en/#signed-request-example-hmac
+func wsAPIPayload(params map[string]any) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%v", k, params[k])
+	}
+	return strings.Join(pairs, "&")
+}
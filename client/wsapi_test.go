@@ -0,0 +1,215 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	c "github.com/michelemendel/binance/constant"
+)
+
+// fakeWSAPI checks the signatures of the requests, with an Ed25519 key if it has one and HMAC otherwise,
+// and answers account.status and order.cancel. It never answers "ignore", and closes the connection on "drop".
+// The server time is answered on REST.
+type fakeWSAPI struct {
+	*httptest.Server
+	publicKey   ed25519.PublicKey
+	connections atomic.Int32
+
+	mu       sync.Mutex
+	requests []wsAPIRequest
+}
+
+func newFakeWSAPI(t *testing.T, publicKey ed25519.PublicKey) *fakeWSAPI {
+	s := &fakeWSAPI{publicKey: publicKey}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == c.PATH_TIME {
+			fmt.Fprintf(w, `{"serverTime":%d}`, time.Now().UnixMilli())
+			return
+		}
+		if r.URL.Path != c.PATH_WS_API {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		s.connections.Add(1)
+		loggedOn := false
+		for {
+			// Numbers are kept as sent, so the signed payload can be rebuilt
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req wsAPIRequest
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.UseNumber()
+			err = decoder.Decode(&req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			s.mu.Lock()
+			s.requests = append(s.requests, req)
+			s.mu.Unlock()
+
+			resp := map[string]any{"id": req.Id, "status": 200}
+			if sig, ok := req.Params["signature"].(string); ok && !s.verify(req.Params, sig) {
+				resp["status"] = 400
+				resp["error"] = map[string]any{"code": c.ERROR_CODE_INVALID_SIGNATURE, "msg": "Signature for this request is not valid."}
+				conn.WriteJSON(resp)
+				continue
+			}
+			switch req.Method {
+			case c.WS_API_SESSION_LOGON:
+				loggedOn = true
+				resp["result"] = map[string]any{"apiKey": req.Params["apiKey"]}
+			case "account.status":
+				if !loggedOn && req.Params["signature"] == nil {
+					resp["status"] = 401
+					resp["error"] = map[string]any{"code": -1002, "msg": "Unauthorized"}
+					break
+				}
+				resp["result"] = map[string]any{"makerCommission": 10, "balances": []map[string]string{{"asset": "BTC", "free": "1.5", "locked": "0"}}}
+				resp["rateLimits"] = []map[string]any{{"rateLimitType": "REQUEST_WEIGHT", "interval": "MINUTE", "intervalNum": 1, "limit": 6000, "count": 42}}
+			case "order.cancel":
+				resp["status"] = 400
+				resp["error"] = map[string]any{"code": c.ERROR_CODE_CANCEL_REJECTED, "msg": "Unknown order sent."}
+			case "ignore":
+				continue
+			case "drop":
+				return
+			}
+			conn.WriteJSON(resp)
+		}
+	}))
+	return s
+}
+
+func (s *fakeWSAPI) verify(params map[string]any, signature string) bool {
+	payload := map[string]any{}
+	for k, v := range params {
+		if k != "signature" {
+			payload[k] = v
+		}
+	}
+	if s.publicKey != nil {
+		sig, err := base64.StdEncoding.DecodeString(signature)
+		return err == nil && ed25519.Verify(s.publicKey, []byte(wsAPIPayload(payload)), sig)
+	}
+	return HMACSign(secretKey, wsAPIPayload(payload)) == signature
+}
+
+func (s *fakeWSAPI) lastRequest() wsAPIRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+func (s *fakeWSAPI) client() *Client {
+	cl := NewClient("test", nil, "apikey", secretKey, s.URL, "")
+	cl.WSAPI = NewWSAPI(cl, "ws"+strings.TrimPrefix(s.URL, "http"))
+	return cl
+}
+
+func TestWSAPI(t *testing.T) {
+	server := newFakeWSAPI(t, nil)
+	defer server.Close()
+	cl := server.client()
+	defer cl.WSAPI.Close()
+
+	// Signed with HMAC, since there's no logon
+	account, err := cl.Account()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := account.Balance("BTC"); b.Free != "1.5" {
+		t.Errorf("BTC balance: got %+v", b)
+	}
+	req := server.lastRequest()
+	if req.Method != "account.status" || req.Params["apiKey"] != "apikey" || req.Params["omitZeroBalances"] != true {
+		t.Errorf("request: got %+v", req)
+	}
+	if used := cl.RateLimiter.Usage().UsedWeight1m; used != 42 {
+		t.Errorf("used weight: got %d, want 42", used)
+	}
+
+	// Binance errors are APIErrors, as on REST
+	_, err = cl.CancelOrder("BTCFDUSD", 1, "")
+	if !IsAPIError(err, c.ERROR_CODE_CANCEL_REJECTED) {
+		t.Errorf("cancel: got %v, want code %d", err, c.ERROR_CODE_CANCEL_REJECTED)
+	}
+	if req := server.lastRequest(); req.Params["orderId"] != json.Number("1") || req.Params["symbol"] != "BTCFDUSD" {
+		t.Errorf("cancel request: got %+v", req)
+	}
+
+	// Requests without a response time out, and requests on a dropped connection fail,
+	// both as retryable errors
+	ctx := context.Background()
+	cl.Timeout = 50 * time.Millisecond
+	err = cl.WSAPI.Request(ctx, "ignore", nil, nil)
+	if !errors.Is(err, errWSAPINoResponse) || !isRetryable(err) {
+		t.Errorf("ignored request: got %v", err)
+	}
+	cl.Timeout = c.TIMEOUT
+	err = cl.WSAPI.Request(ctx, "drop", nil, nil)
+	if !errors.Is(err, errWSAPINoResponse) || !isRetryable(err) {
+		t.Errorf("dropped request: got %v", err)
+	}
+
+	// The next request reconnects
+	_, err = cl.Account()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := server.connections.Load(); n != 2 {
+		t.Errorf("connections: got %d, want 2", n)
+	}
+}
+
+func TestWSAPILogon(t *testing.T) {
+	signer, err := NewEd25519Signer([]byte(ed25519Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newFakeWSAPI(t, signer.key.Public().(ed25519.PublicKey))
+	defer server.Close()
+	cl := server.client()
+	cl.Signer = signer
+	defer cl.WSAPI.Close()
+
+	_, err = cl.Account()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cl.WSAPI.LoggedOn() {
+		t.Error("not logged on")
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.requests) != 2 || server.requests[0].Method != c.WS_API_SESSION_LOGON {
+		t.Fatalf("requests: got %+v", server.requests)
+	}
+	// Logged on, so only the timestamp is added
+	params := server.requests[1].Params
+	if params["signature"] != nil || params["apiKey"] != nil || params["timestamp"] == nil {
+		t.Errorf("account request: got %+v", params)
+	}
+}
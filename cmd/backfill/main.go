@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/michelemendel/binance/backfill"
+	"github.com/michelemendel/binance/client"
+	c "github.com/michelemendel/binance/constant"
+)
+
+// Backfills historical klines to CSV files, resuming from the last kline stored, e.g.
+// go run cmd/backfill/main.go -symbols BTCFDUSD,ETHFDUSD -interval 1h -from 2024-01-01
+
+func init() {
+	envFile := filepath.Join("", ".env")
+	err := godotenv.Load(envFile)
+	if err != nil {
+		slog.Error("error loading file ", "file", envFile, "error", err)
+	}
+}
+
+func main() {
+	symbols := flag.String("symbols", "BTCFDUSD", "comma separated symbols")
+	interval := flag.String("interval", "1h", "kline interval, e.g. 1m, 1h, 1d")
+	from := flag.String("from", "2024-01-01", "start date of a new file, YYYY-MM-DD")
+	dir := flag.String("dir", "data", "directory of the CSV files")
+	flag.Parse()
+
+	start, err := time.Parse(time.DateOnly, *from)
+	if err != nil {
+		fmt.Println("error parsing -from:", err)
+		os.Exit(1)
+	}
+
+	baseAPI := c.BASE_API_PROD_0
+	if os.Getenv("ENV") == "test" {
+		baseAPI = c.BASE_API_TEST
+	}
+	// Klines are public, no keys needed
+	cl := client.NewClient(os.Getenv("ENV"), nil, "", "", baseAPI, "")
+
+	for _, symbol := range strings.Split(*symbols, ",") {
+		added, err := backfill.Klines(cl, *dir, symbol, *interval, start)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s: added %d klines to %s\n", symbol, added, backfill.Path(*dir, symbol, *interval))
+	}
+}
@@ -40,17 +40,158 @@ const (
 	PATH_PING               = "/api/v3/ping"
 	PATH_TIME               = "/api/v3/time"
 	PATH_EXCHANGE_INFO      = "/api/v3/exchangeInfo"
+	PATH_TICKER_PRICE       = "/api/v3/ticker/price"
+	PATH_KLINES             = "/api/v3/klines"
+	PATH_DEPTH              = "/api/v3/depth"
+	PATH_ORDER              = "/api/v3/order"
+	PATH_ORDER_TEST         = "/api/v3/order/test"
+	PATH_ORDER_OCO          = "/api/v3/order/oco"
+	PATH_CANCEL_REPLACE     = "/api/v3/order/cancelReplace"
+	PATH_OPEN_ORDERS        = "/api/v3/openOrders"
+	PATH_ALL_ORDERS         = "/api/v3/allOrders"
+	PATH_MY_TRADES          = "/api/v3/myTrades"
+	PATH_USER_DATA_STREAM   = "/api/v3/userDataStream"
+	PATH_ACCOUNT            = "/api/v3/account"
 	PATH_GET_ACCOUNT_STATUS = "/sapi/v1/account/status"
+	PATH_TRADE_FEE          = "/sapi/v1/asset/tradeFee"
+	PATH_WALLET_BALANCE     = "/sapi/v1/asset/wallet/balance"
 	PATH_WALLET_STATUS      = "/sapi/v1/system/status"
 )
 
+// Endpoint security types
+// https://binance-docs.github.io/apidocs/spot/en/#endpoint-security-type
+// USER_STREAM and MARKET_DATA need the API key, TRADE and USER_DATA need the API key and a signature.
+const (
+	SECURITY_TYPE_NONE        = "NONE"
+	SECURITY_TYPE_USER_STREAM = "USER_STREAM"
+	SECURITY_TYPE_MARKET_DATA = "MARKET_DATA"
+	SECURITY_TYPE_TRADE       = "TRADE"
+	SECURITY_TYPE_USER_DATA   = "USER_DATA"
+)
+
+// API key types
+// https://binance-docs.github.io/apidocs/spot/en/#signed-trade-user_data-and-margin-endpoint-security
+const (
+	KEY_TYPE_HMAC    = "HMAC"
+	KEY_TYPE_RSA     = "RSA"
+	KEY_TYPE_ED25519 = "ED25519"
+)
+
+// Error codes
+// https://binance-docs.github.io/apidocs/spot/en/#error-codes
+const (
+	ERROR_CODE_UNKNOWN             = -1000
+	ERROR_CODE_DISCONNECTED        = -1001
+	ERROR_CODE_TOO_MANY_REQUESTS   = -1003
+	ERROR_CODE_TIMEOUT             = -1007
+	ERROR_CODE_INVALID_TIMESTAMP   = -1021 // Timestamp outside of recvWindow, or ahead of server time
+	ERROR_CODE_INVALID_SIGNATURE   = -1022
+	ERROR_CODE_BAD_SYMBOL          = -1121
+	ERROR_CODE_INVALID_LISTEN_KEY  = -1125 // The listenKey does not exist, e.g. it has expired
+	ERROR_CODE_NEW_ORDER_REJECTED  = -2010 // E.g. insufficient balance
+	ERROR_CODE_CANCEL_REJECTED     = -2011
+	ERROR_CODE_NO_SUCH_ORDER       = -2013
+	ERROR_CODE_REJECTED_MBX_KEY    = -2015
+	ERROR_CODE_CANCEL_REPLACE_FAIL = -2022
+)
+
+// Order sides
+const (
+	SIDE_BUY  = "BUY"
+	SIDE_SELL = "SELL"
+)
+
+// Order types
+// https://binance-docs.github.io/apidocs/spot/en/#public-api-definitions
+const (
+	ORDER_TYPE_MARKET            = "MARKET"
+	ORDER_TYPE_LIMIT             = "LIMIT"
+	ORDER_TYPE_LIMIT_MAKER       = "LIMIT_MAKER"
+	ORDER_TYPE_STOP_LOSS         = "STOP_LOSS"
+	ORDER_TYPE_STOP_LOSS_LIMIT   = "STOP_LOSS_LIMIT"
+	ORDER_TYPE_TAKE_PROFIT       = "TAKE_PROFIT"
+	ORDER_TYPE_TAKE_PROFIT_LIMIT = "TAKE_PROFIT_LIMIT"
+)
+
+// Time in force
+// GTC - Good Til Canceled, IOC - Immediate Or Cancel, FOK - Fill or Kill
+const (
+	TIME_IN_FORCE_GTC = "GTC"
+	TIME_IN_FORCE_IOC = "IOC"
+	TIME_IN_FORCE_FOK = "FOK"
+)
+
+// Cancel-replace modes
+// STOP_ON_FAILURE - the new order is only placed if the cancel succeeds
+// ALLOW_FAILURE - the new order is placed even if the cancel fails
+const (
+	CANCEL_REPLACE_STOP_ON_FAILURE = "STOP_ON_FAILURE"
+	CANCEL_REPLACE_ALLOW_FAILURE   = "ALLOW_FAILURE"
+)
+
+// Order book limits
+const (
+	DEPTH_LIMIT_DEFAULT = 1000
+	DEPTH_LIMIT_MAX     = 5000
+)
+
+// Cost basis methods of the PnL engine
+const (
+	COST_BASIS_FIFO    = "FIFO"
+	COST_BASIS_LIFO    = "LIFO"
+	COST_BASIS_AVERAGE = "AVERAGE"
+)
+
+// Symbol status
+const (
+	SYMBOL_STATUS_TRADING = "TRADING"
+)
+
+// Symbol filters
+// https://binance-docs.github.io/apidocs/spot/en/#filters
+const (
+	FILTER_PRICE           = "PRICE_FILTER"
+	FILTER_LOT_SIZE        = "LOT_SIZE"
+	FILTER_MARKET_LOT_SIZE = "MARKET_LOT_SIZE"
+	FILTER_MIN_NOTIONAL    = "MIN_NOTIONAL"
+	FILTER_NOTIONAL        = "NOTIONAL"
+	FILTER_ICEBERG_PARTS   = "ICEBERG_PARTS"
+)
+
+// Limits on Binance queries
+const (
+	MAX_QUERY_LIMIT  = 1000           // Max number of rows returned by a list query
+	MAX_QUERY_WINDOW = 24 * time.Hour // Max time between startTime and endTime for allOrders and myTrades
+)
+
+// Rate limits, used until they are loaded from exchangeInfo
+// https://binance-docs.github.io/apidocs/spot/en/#limits
+const (
+	RATE_LIMIT_REQUEST_WEIGHT = "REQUEST_WEIGHT"
+	RATE_LIMIT_ORDERS         = "ORDERS"
+	DEFAULT_WEIGHT_LIMIT_1M   = 6000
+	DEFAULT_ORDER_LIMIT_10S   = 100
+	DEFAULT_ORDER_LIMIT_1D    = 200000
+	HEADER_USED_WEIGHT_1M     = "X-MBX-USED-WEIGHT-1M"
+	HEADER_ORDER_COUNT_10S    = "X-MBX-ORDER-COUNT-10S"
+	HEADER_ORDER_COUNT_1D     = "X-MBX-ORDER-COUNT-1D"
+	HEADER_RETRY_AFTER        = "Retry-After"
+	MAX_RATE_LIMIT_WAIT       = 1 * time.Minute // Longer waits, e.g. an IP ban, are returned as errors
+)
+
+// Order response types
+const (
+	ORDER_RESP_TYPE_ACK    = "ACK"
+	ORDER_RESP_TYPE_RESULT = "RESULT"
+	ORDER_RESP_TYPE_FULL   = "FULL"
+)
+
 // TODO: Not sure I need these, since they are already set in the paths above.
 // API path types
 // const (
 // 	PATH_API    = "/api"
 // 	PATH_SAPI   = "/sapi"
 // 	PATH_WS     = "/ws"
-// 	PATH_WS_API = "/ws-api/v3"
 // 	PATH_STREAM = "/stream"
 // )
 
@@ -58,3 +199,103 @@ const (
 	TIMEOUT_DURATION_MILLISECOND = 10000
 	TIMEOUT                      = time.Duration(TIMEOUT_DURATION_MILLISECOND) * time.Millisecond
 )
+
+// Signed requests are rejected if they reach the server more than recvWindow after their timestamp
+// https://binance-docs.github.io/apidocs/spot/en/#timing-security
+const (
+	RECV_WINDOW_DURATION_MILLISECOND = 5000
+	RECV_WINDOW                      = time.Duration(RECV_WINDOW_DURATION_MILLISECOND) * time.Millisecond
+	MAX_RECV_WINDOW                  = 60 * time.Second
+	TIME_SYNC_INTERVAL               = 10 * time.Minute
+	TIME_SYNC_SAMPLES                = 3
+	TIME_SYNC_RETRY                  = 5 * time.Second // After a failed sync, doubled for each failure in a row
+)
+
+// Retries of requests that fail because of the network or the server
+const (
+	RETRY_MAX_ATTEMPTS = 3
+	RETRY_BASE_DELAY   = 200 * time.Millisecond
+	RETRY_MAX_DELAY    = 5 * time.Second
+)
+
+// Stream connections are closed by the server at 24 hours, and if no pong is received for 10 minutes
+const (
+	WS_CONNECTION_LIFETIME = 23 * time.Hour   // Connections are replaced before the server closes them
+	WS_ROLL_RETRY          = 1 * time.Minute  // Wait before trying again to replace a connection
+	WS_READ_TIMEOUT        = 10 * time.Minute // A connection is considered dead if nothing is received for this long
+	WS_BUFFER_SIZE         = 1000             // Events buffered for a slow consumer
+)
+
+// Streams of all symbols
+const (
+	STREAM_ALL_TICKERS      = "!ticker@arr"
+	STREAM_ALL_MINI_TICKERS = "!miniTicker@arr"
+)
+
+// Market stream event types, the "e" field of the events. Events without one are given a name here.
+const (
+	EVENT_AGG_TRADE        = "aggTrade"
+	EVENT_TRADE            = "trade"
+	EVENT_KLINE            = "kline"
+	EVENT_BOOK_TICKER      = "bookTicker"   // No "e" field
+	EVENT_PARTIAL_DEPTH    = "partialDepth" // No "e" field
+	EVENT_DEPTH_UPDATE     = "depthUpdate"
+	EVENT_AVG_PRICE        = "avgPrice"
+	EVENT_TICKER           = "24hrTicker"
+	EVENT_MINI_TICKER      = "24hrMiniTicker"
+	EVENT_ALL_TICKERS      = "!ticker@arr"     // An array of EVENT_TICKER
+	EVENT_ALL_MINI_TICKERS = "!miniTicker@arr" // An array of EVENT_MINI_TICKER
+)
+
+// WebSocket API, an alternative to the REST API over one connection
+// Session logon only works with Ed25519 keys
+// https://binance-docs.github.io/apidocs/websocket_api/en/
+const (
+	PATH_WS_API          = "/ws-api/v3"
+	WS_API_SESSION_LOGON = "session.logon"
+)
+
+// Live subscribing and unsubscribing to streams, limited to 1024 streams per connection
+// and 5 incoming messages per second
+// https://binance-docs.github.io/apidocs/spot/en/#live-subscribing-unsubscribing-to-streams
+const (
+	WS_METHOD_SUBSCRIBE          = "SUBSCRIBE"
+	WS_METHOD_UNSUBSCRIBE        = "UNSUBSCRIBE"
+	WS_METHOD_LIST_SUBSCRIPTIONS = "LIST_SUBSCRIPTIONS"
+	WS_MAX_STREAMS               = 1024
+	WS_MAX_MESSAGES_PER_SECOND   = 5
+)
+
+// User data stream event types, the "e" field of the events
+// https://binance-docs.github.io/apidocs/spot/en/#user-data-streams
+const (
+	EVENT_EXECUTION_REPORT   = "executionReport"
+	EVENT_ACCOUNT_POSITION   = "outboundAccountPosition"
+	EVENT_BALANCE_UPDATE     = "balanceUpdate"
+	EVENT_LISTEN_KEY_EXPIRED = "listenKeyExpired"
+)
+
+// Execution type of an execution report that is a fill
+const (
+	EXECUTION_TYPE_TRADE = "TRADE"
+)
+
+// A listenKey expires 60 minutes after it was created or last kept alive
+const (
+	LISTEN_KEY_KEEPALIVE = 30 * time.Minute
+)
+
+// How often the REST API hosts are health checked
+const (
+	HEALTH_CHECK_INTERVAL = 5 * time.Minute
+)
+
+// Default location of the trade journal, overridden by JOURNAL_PATH
+const (
+	JOURNAL_PATH = "journal.db"
+)
+
+// How long the cached exchange information is used before it is fetched again
+const (
+	SYMBOL_REFRESH_INTERVAL = 1 * time.Hour
+)
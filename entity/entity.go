@@ -1,5 +1,13 @@
 package entity
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+)
+
 // --------------------------------------------------------------------------------
 type PingResp struct{}
 
@@ -8,6 +16,552 @@ type TimeResp struct {
 	ServerTime uint64 `json:"serverTime"`
 }
 
+// --------------------------------------------------------------------------------
+// Order
+
+// OrderRequest describes a new order. Which fields are required depends on Type.
+// MARKET: Quantity or QuoteOrderQty
+// LIMIT: TimeInForce, Quantity, Price
+// LIMIT_MAKER: Quantity, Price
+// STOP_LOSS, TAKE_PROFIT: Quantity, StopPrice
+// STOP_LOSS_LIMIT, TAKE_PROFIT_LIMIT: TimeInForce, Quantity, Price, StopPrice
+type OrderRequest struct {
+	Symbol           string
+	Side             string // Set by Buy and Sell
+	Type             string // Defaults to MARKET
+	TimeInForce      string // GTC, IOC or FOK
+	Quantity         float64
+	QuoteOrderQty    float64 // MARKET only
+	Price            float64
+	StopPrice        float64
+	IcebergQty       float64
+	NewClientOrderId string // Generated if empty, used to find the order if a request fails
+	DryRun           bool   // Send to the test endpoint, see Client.DryRun
+	Strategy         string //Not part of API, recorded in the journal with the order
+}
+
+// OCORequest describes an OCO order: a LIMIT_MAKER order at Price and a stop order triggered at StopPrice.
+// For a SELL, Price is the take-profit above the market and StopPrice the stop-loss below it; the reverse for a BUY.
+// The stop order is a STOP_LOSS_LIMIT order if StopLimitPrice is set, otherwise a STOP_LOSS order.
+type OCORequest struct {
+	Symbol               string
+	Side                 string
+	Quantity             float64
+	Price                float64
+	StopPrice            float64
+	StopLimitPrice       float64
+	StopLimitTimeInForce string // Required with StopLimitPrice
+	ListClientOrderId    string // Optional
+}
+
+// New Order (FULL response), also used for the new order in Cancel an Existing Order and Send a New Order
+// https://binance-docs.github.io/apidocs/spot/en/#new-order-trade
+type CreateOrderResp struct {
+	Symbol                  string `json:"symbol"`
+	OrderId                 int64  `json:"orderId"`
+	OrderListId             int64  `json:"orderListId"`
+	ClientOrderId           string `json:"clientOrderId"`
+	TransactTime            uint64 `json:"transactTime"`
+	Price                   string `json:"price"`
+	OrigQty                 string `json:"origQty"`
+	ExecutedQty             string `json:"executedQty"`
+	CummulativeQuoteQty     string `json:"cummulativeQuoteQty"`
+	Status                  string `json:"status"`
+	TimeInForce             string `json:"timeInForce"`
+	Type                    string `json:"type"`
+	Side                    string `json:"side"`
+	StopPrice               string `json:"stopPrice,omitempty"`
+	IcebergQty              string `json:"icebergQty,omitempty"`
+	WorkingTime             uint64 `json:"workingTime"`
+	SelfTradePreventionMode string `json:"selfTradePreventionMode"`
+	Fills                   []Fill `json:"fills"`
+
+	DryRun          bool           `json:"dryRun,omitempty"`          //Not part of API
+	CommissionRates *TestOrderResp `json:"commissionRates,omitempty"` //Not part of API, set for dry runs
+}
+
+// Test New Order with computeCommissionRates=true
+// https://binance-docs.github.io/apidocs/spot/en/#test-new-order-trade
+type TestOrderResp struct {
+	StandardCommissionForOrder struct {
+		Maker string `json:"maker"`
+		Taker string `json:"taker"`
+	} `json:"standardCommissionForOrder"`
+	TaxCommissionForOrder struct {
+		Maker string `json:"maker"`
+		Taker string `json:"taker"`
+	} `json:"taxCommissionForOrder"`
+	Discount struct {
+		EnabledForAccount bool   `json:"enabledForAccount"`
+		EnabledForSymbol  bool   `json:"enabledForSymbol"`
+		DiscountAsset     string `json:"discountAsset"`
+		Discount          string `json:"discount"`
+	} `json:"discount"`
+}
+
+type Fill struct {
+	Price           string `json:"price"`
+	Qty             string `json:"qty"`
+	Commission      string `json:"commission"`
+	CommissionAsset string `json:"commissionAsset"`
+	TradeId         int64  `json:"tradeId"`
+}
+
+// New OCO
+// https://binance-docs.github.io/apidocs/spot/en/#new-oco-trade
+type OrderList struct {
+	OrderListId       int64  `json:"orderListId"`
+	ContingencyType   string `json:"contingencyType"`
+	ListStatusType    string `json:"listStatusType"`
+	ListOrderStatus   string `json:"listOrderStatus"`
+	ListClientOrderId string `json:"listClientOrderId"`
+	TransactionTime   uint64 `json:"transactionTime"`
+	Symbol            string `json:"symbol"`
+	Orders            []struct {
+		Symbol        string `json:"symbol"`
+		OrderId       int64  `json:"orderId"`
+		ClientOrderId string `json:"clientOrderId"`
+	} `json:"orders"`
+	OrderReports []CreateOrderResp `json:"orderReports"`
+}
+
+// Cancel an Existing Order and Send a New Order
+// https://binance-docs.github.io/apidocs/spot/en/#cancel-an-existing-order-and-send-a-new-order-trade
+type CancelReplaceResp struct {
+	CancelResult     string           `json:"cancelResult"`   // SUCCESS, FAILURE
+	NewOrderResult   string           `json:"newOrderResult"` // SUCCESS, FAILURE, NOT_ATTEMPTED
+	CancelResponse   *CanceledOrder   `json:"cancelResponse"`
+	NewOrderResponse *CreateOrderResp `json:"newOrderResponse"`
+}
+
+// Query Order, Current Open Orders and All Orders
+// https://binance-docs.github.io/apidocs/spot/en/#query-order-user_data
+type Order struct {
+	Symbol                  string `json:"symbol"`
+	OrderId                 int64  `json:"orderId"`
+	OrderListId             int64  `json:"orderListId"` // -1 unless the order is part of an order list
+	ClientOrderId           string `json:"clientOrderId"`
+	Price                   string `json:"price"`
+	OrigQty                 string `json:"origQty"`
+	ExecutedQty             string `json:"executedQty"`
+	CummulativeQuoteQty     string `json:"cummulativeQuoteQty"` // Sic
+	Status                  string `json:"status"`
+	TimeInForce             string `json:"timeInForce"`
+	Type                    string `json:"type"`
+	Side                    string `json:"side"`
+	StopPrice               string `json:"stopPrice"`
+	IcebergQty              string `json:"icebergQty"`
+	Time                    uint64 `json:"time"`
+	UpdateTime              uint64 `json:"updateTime"`
+	IsWorking               bool   `json:"isWorking"`
+	WorkingTime             uint64 `json:"workingTime"`
+	OrigQuoteOrderQty       string `json:"origQuoteOrderQty"`
+	SelfTradePreventionMode string `json:"selfTradePreventionMode"`
+}
+
+// Cancel Order and Cancel all Open Orders on a Symbol
+// https://binance-docs.github.io/apidocs/spot/en/#cancel-order-trade
+type CanceledOrder struct {
+	Symbol                  string `json:"symbol"`
+	OrigClientOrderId       string `json:"origClientOrderId"`
+	OrderId                 int64  `json:"orderId"`
+	OrderListId             int64  `json:"orderListId"`
+	ClientOrderId           string `json:"clientOrderId"`
+	TransactTime            uint64 `json:"transactTime"`
+	Price                   string `json:"price"`
+	OrigQty                 string `json:"origQty"`
+	ExecutedQty             string `json:"executedQty"`
+	CummulativeQuoteQty     string `json:"cummulativeQuoteQty"`
+	Status                  string `json:"status"`
+	TimeInForce             string `json:"timeInForce"`
+	Type                    string `json:"type"`
+	Side                    string `json:"side"`
+	SelfTradePreventionMode string `json:"selfTradePreventionMode"`
+}
+
+// Account Trade List, one fill of an order
+// https://binance-docs.github.io/apidocs/spot/en/#account-trade-list-user_data
+type Trade struct {
+	Symbol          string `json:"symbol"`
+	Id              int64  `json:"id"`
+	OrderId         int64  `json:"orderId"`
+	OrderListId     int64  `json:"orderListId"`
+	Price           string `json:"price"`
+	Qty             string `json:"qty"`
+	QuoteQty        string `json:"quoteQty"`
+	Commission      string `json:"commission"`
+	CommissionAsset string `json:"commissionAsset"`
+	Time            uint64 `json:"time"`
+	IsBuyer         bool   `json:"isBuyer"`
+	IsMaker         bool   `json:"isMaker"`
+	IsBestMatch     bool   `json:"isBestMatch"`
+}
+
+// --------------------------------------------------------------------------------
+// Market data
+
+// Symbol Price Ticker
+type PriceTickerResp struct {
+	Symbol string `json:"symbol"`
+	Price  string `json:"price"`
+}
+
+// Order Book
+// https://binance-docs.github.io/apidocs/spot/en/#order-book
+// Bids and asks are [price, quantity] pairs, bids in descending and asks in ascending price order.
+type DepthResp struct {
+	LastUpdateId int64       `json:"lastUpdateId"`
+	Bids         [][2]string `json:"bids"`
+	Asks         [][2]string `json:"asks"`
+}
+
+// Kline/Candlestick Data
+// https://binance-docs.github.io/apidocs/spot/en/#kline-candlestick-data
+// Klines are returned as arrays, not objects.
+type Kline struct {
+	OpenTime                 uint64
+	Open                     string
+	High                     string
+	Low                      string
+	Close                    string
+	Volume                   string
+	CloseTime                uint64
+	QuoteAssetVolume         string
+	NumberOfTrades           int64
+	TakerBuyBaseAssetVolume  string
+	TakerBuyQuoteAssetVolume string
+}
+
+func (k *Kline) UnmarshalJSON(data []byte) error {
+	var ignore any
+	fields := []any{
+		&k.OpenTime, &k.Open, &k.High, &k.Low, &k.Close, &k.Volume, &k.CloseTime,
+		&k.QuoteAssetVolume, &k.NumberOfTrades, &k.TakerBuyBaseAssetVolume, &k.TakerBuyQuoteAssetVolume, &ignore,
+	}
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return fmt.Errorf("error decoding kline: %w", err)
+	}
+	return nil
+}
+
+// --------------------------------------------------------------------------------
+// Market streams
+// https://binance-docs.github.io/apidocs/spot/en/#websocket-market-streams
+
+// MarketEvent is implemented by the events of all market streams, so they can be delivered on one channel.
+// Use a type switch to handle the events by type.
+type MarketEvent interface {
+	EventType() string   // The "e" field, or a name for events without one, see EVENT_*
+	EventSymbol() string // Empty for events of all symbols
+}
+
+// Aggregate Trade Streams, trades that fill at the same time, from the same taker order, at the same price
+// https://binance-docs.github.io/apidocs/spot/en/#aggregate-trade-streams
+type AggTradeEvent struct {
+	Event        string `json:"e"`
+	Time         int64  `json:"E"`
+	Symbol       string `json:"s"`
+	AggTradeId   int64  `json:"a"`
+	Price        string `json:"p"`
+	Qty          string `json:"q"`
+	FirstTradeId int64  `json:"f"`
+	LastTradeId  int64  `json:"l"`
+	TradeTime    int64  `json:"T"`
+	IsBuyerMaker bool   `json:"m"`
+	IsBestMatch  bool   `json:"M"` // Ignore
+}
+
+func (e AggTradeEvent) EventType() string   { return e.Event }
+func (e AggTradeEvent) EventSymbol() string { return e.Symbol }
+
+// Trade Streams
+// https://binance-docs.github.io/apidocs/spot/en/#trade-streams
+type TradeEvent struct {
+	Event        string `json:"e"`
+	Time         int64  `json:"E"`
+	Symbol       string `json:"s"`
+	TradeId      int64  `json:"t"`
+	Price        string `json:"p"`
+	Qty          string `json:"q"`
+	TradeTime    int64  `json:"T"`
+	IsBuyerMaker bool   `json:"m"`
+	IsBestMatch  bool   `json:"M"` // Ignore
+}
+
+func (e TradeEvent) EventType() string   { return e.Event }
+func (e TradeEvent) EventSymbol() string { return e.Symbol }
+
+// Kline/Candlestick Streams, pushed every second while the kline is open
+// https://binance-docs.github.io/apidocs/spot/en/#kline-candlestick-streams
+type KlineEvent struct {
+	Event  string      `json:"e"`
+	Time   int64       `json:"E"`
+	Symbol string      `json:"s"`
+	Kline  StreamKline `json:"k"`
+}
+
+type StreamKline struct {
+	OpenTime                 uint64 `json:"t"`
+	CloseTime                uint64 `json:"T"`
+	Symbol                   string `json:"s"`
+	Interval                 string `json:"i"`
+	FirstTradeId             int64  `json:"f"`
+	LastTradeId              int64  `json:"L"`
+	Open                     string `json:"o"`
+	Close                    string `json:"c"`
+	High                     string `json:"h"`
+	Low                      string `json:"l"`
+	Volume                   string `json:"v"`
+	NumberOfTrades           int64  `json:"n"`
+	IsClosed                 bool   `json:"x"`
+	QuoteAssetVolume         string `json:"q"`
+	TakerBuyBaseAssetVolume  string `json:"V"`
+	TakerBuyQuoteAssetVolume string `json:"Q"`
+}
+
+func (e KlineEvent) EventType() string   { return e.Event }
+func (e KlineEvent) EventSymbol() string { return e.Symbol }
+
+// Kline returns the kline as returned by the REST API.
+func (k StreamKline) Kline() Kline {
+	return Kline{
+		OpenTime:                 k.OpenTime,
+		Open:                     k.Open,
+		High:                     k.High,
+		Low:                      k.Low,
+		Close:                    k.Close,
+		Volume:                   k.Volume,
+		CloseTime:                k.CloseTime,
+		QuoteAssetVolume:         k.QuoteAssetVolume,
+		NumberOfTrades:           k.NumberOfTrades,
+		TakerBuyBaseAssetVolume:  k.TakerBuyBaseAssetVolume,
+		TakerBuyQuoteAssetVolume: k.TakerBuyQuoteAssetVolume,
+	}
+}
+
+// Individual Symbol Book Ticker Streams, the best bid and ask in real time
+// https://binance-docs.github.io/apidocs/spot/en/#individual-symbol-book-ticker-streams
+type BookTickerEvent struct {
+	UpdateId int64  `json:"u"`
+	Symbol   string `json:"s"`
+	BidPrice string `json:"b"`
+	BidQty   string `json:"B"`
+	AskPrice string `json:"a"`
+	AskQty   string `json:"A"`
+}
+
+func (e BookTickerEvent) EventType() string   { return c.EVENT_BOOK_TICKER }
+func (e BookTickerEvent) EventSymbol() string { return e.Symbol }
+
+// Partial Book Depth Streams, the top 5, 10 or 20 levels of the book
+// https://binance-docs.github.io/apidocs/spot/en/#partial-book-depth-streams
+type PartialDepthEvent struct {
+	Symbol string `json:"-"` //Not part of API, taken from the stream name
+	DepthResp
+}
+
+func (e PartialDepthEvent) EventType() string   { return c.EVENT_PARTIAL_DEPTH }
+func (e PartialDepthEvent) EventSymbol() string { return e.Symbol }
+
+// Diff. Depth Stream event, the changes to the order book between two update ids.
+// A quantity of 0 means the price level is removed.
+// https://binance-docs.github.io/apidocs/spot/en/#diff-depth-stream
+type DepthUpdate struct {
+	Event         string      `json:"e"`
+	Time          int64       `json:"E"`
+	Symbol        string      `json:"s"`
+	FirstUpdateId int64       `json:"U"`
+	FinalUpdateId int64       `json:"u"`
+	Bids          [][2]string `json:"b"`
+	Asks          [][2]string `json:"a"`
+}
+
+func (e DepthUpdate) EventType() string   { return e.Event }
+func (e DepthUpdate) EventSymbol() string { return e.Symbol }
+
+// Average Price stream, the average price over the interval
+// https://binance-docs.github.io/apidocs/spot/en/#average-price
+type AvgPriceEvent struct {
+	Event         string `json:"e"`
+	Time          int64  `json:"E"`
+	Symbol        string `json:"s"`
+	Interval      string `json:"i"`
+	Price         string `json:"w"`
+	LastTradeTime int64  `json:"T"`
+}
+
+func (e AvgPriceEvent) EventType() string   { return e.Event }
+func (e AvgPriceEvent) EventSymbol() string { return e.Symbol }
+
+// Individual Symbol Mini Ticker Stream event, the rolling 24 hour statistics of a symbol
+// https://binance-docs.github.io/apidocs/spot/en/#individual-symbol-mini-ticker-stream
+type MiniTickerEvent struct {
+	Event       string `json:"e"`
+	Time        int64  `json:"E"`
+	Symbol      string `json:"s"`
+	Close       string `json:"c"`
+	Open        string `json:"o"`
+	High        string `json:"h"`
+	Low         string `json:"l"`
+	Volume      string `json:"v"` // Base asset
+	QuoteVolume string `json:"q"`
+}
+
+func (e MiniTickerEvent) EventType() string   { return e.Event }
+func (e MiniTickerEvent) EventSymbol() string { return e.Symbol }
+
+// All Market Mini Tickers Stream, the mini tickers of the symbols that changed
+// https://binance-docs.github.io/apidocs/spot/en/#all-market-mini-tickers-stream
+type AllMiniTickersEvent []MiniTickerEvent
+
+func (e AllMiniTickersEvent) EventType() string   { return c.EVENT_ALL_MINI_TICKERS }
+func (e AllMiniTickersEvent) EventSymbol() string { return "" }
+
+// Individual Symbol Ticker Streams, the rolling 24 hour statistics of a symbol
+// https://binance-docs.github.io/apidocs/spot/en/#individual-symbol-ticker-streams
+type TickerEvent struct {
+	Event              string `json:"e"`
+	Time               int64  `json:"E"`
+	Symbol             string `json:"s"`
+	PriceChange        string `json:"p"`
+	PriceChangePercent string `json:"P"`
+	WeightedAvgPrice   string `json:"w"`
+	FirstTradePrice    string `json:"x"` // Before the 24 hour window
+	LastPrice          string `json:"c"`
+	LastQty            string `json:"Q"`
+	BidPrice           string `json:"b"`
+	BidQty             string `json:"B"`
+	AskPrice           string `json:"a"`
+	AskQty             string `json:"A"`
+	OpenPrice          string `json:"o"`
+	HighPrice          string `json:"h"`
+	LowPrice           string `json:"l"`
+	Volume             string `json:"v"` // Base asset
+	QuoteVolume        string `json:"q"`
+	OpenTime           int64  `json:"O"`
+	CloseTime          int64  `json:"C"`
+	FirstTradeId       int64  `json:"F"`
+	LastTradeId        int64  `json:"L"`
+	NumberOfTrades     int64  `json:"n"`
+}
+
+func (e TickerEvent) EventType() string   { return e.Event }
+func (e TickerEvent) EventSymbol() string { return e.Symbol }
+
+// All Market Tickers Stream, the tickers of the symbols that changed
+// https://binance-docs.github.io/apidocs/spot/en/#all-market-tickers-stream
+type AllTickersEvent []TickerEvent
+
+func (e AllTickersEvent) EventType() string   { return c.EVENT_ALL_TICKERS }
+func (e AllTickersEvent) EventSymbol() string { return "" }
+
+// --------------------------------------------------------------------------------
+// User data streams
+// https://binance-docs.github.io/apidocs/spot/en/#user-data-streams
+
+type ListenKeyResp struct {
+	ListenKey string `json:"listenKey"`
+}
+
+// UserDataEvent is implemented by the events of the user data stream.
+// Use a type switch to handle the events by type.
+type UserDataEvent interface {
+	EventType() string // The "e" field, see EVENT_*
+}
+
+// Order Update, sent when an order is placed, filled, cancelled, expires or is rejected
+// https://binance-docs.github.io/apidocs/spot/en/#order-update
+// All fields are listed, including the ignored ones, since JSON field names are matched case-insensitively.
+type ExecutionReport struct {
+	Event                   string `json:"e"`
+	Time                    int64  `json:"E"`
+	Symbol                  string `json:"s"`
+	ClientOrderId           string `json:"c"`
+	Side                    string `json:"S"`
+	Type                    string `json:"o"`
+	TimeInForce             string `json:"f"`
+	OrigQty                 string `json:"q"`
+	Price                   string `json:"p"`
+	StopPrice               string `json:"P"`
+	IcebergQty              string `json:"F"`
+	OrderListId             int64  `json:"g"`
+	OrigClientOrderId       string `json:"C"` // Of the cancelled order, else empty
+	ExecutionType           string `json:"x"` // NEW, CANCELED, REPLACED, REJECTED, TRADE, EXPIRED, TRADE_PREVENTION
+	Status                  string `json:"X"`
+	RejectReason            string `json:"r"`
+	OrderId                 int64  `json:"i"`
+	LastExecutedQty         string `json:"l"`
+	CumulativeFilledQty     string `json:"z"`
+	LastExecutedPrice       string `json:"L"`
+	Commission              string `json:"n"`
+	CommissionAsset         string `json:"N"` // Null unless ExecutionType is TRADE
+	TransactionTime         int64  `json:"T"`
+	TradeId                 int64  `json:"t"` // -1 unless ExecutionType is TRADE
+	PreventedMatchId        int64  `json:"v"` // Only if the order expired by self-trade prevention
+	Ignore                  int64  `json:"I"`
+	IsOnBook                bool   `json:"w"`
+	IsMaker                 bool   `json:"m"`
+	IgnoreM                 bool   `json:"M"`
+	CreationTime            int64  `json:"O"`
+	CumulativeQuoteQty      string `json:"Z"`
+	LastQuoteQty            string `json:"Y"`
+	QuoteOrderQty           string `json:"Q"`
+	WorkingTime             int64  `json:"W"`
+	SelfTradePreventionMode string `json:"V"`
+	// Conditional fields, only sent when they apply
+	TrailingDelta    int64  `json:"d"`
+	TrailingTime     int64  `json:"D"`
+	StrategyId       int64  `json:"j"`
+	StrategyType     int64  `json:"J"`
+	PreventedQty     string `json:"A"`
+	LastPreventedQty string `json:"B"`
+	TradeGroupId     int64  `json:"u"`
+	CounterOrderId   int64  `json:"U"`
+	AllocationId     int64  `json:"a"`
+	MatchType        string `json:"b"`
+}
+
+func (e ExecutionReport) EventType() string { return e.Event }
+
+// Account Update, the balances of the assets that changed, sent whenever the account balance changes
+// https://binance-docs.github.io/apidocs/spot/en/#account-update
+type AccountPositionEvent struct {
+	Event          string                   `json:"e"`
+	Time           int64                    `json:"E"`
+	LastUpdateTime int64                    `json:"u"`
+	Balances       []AccountPositionBalance `json:"B"`
+}
+
+type AccountPositionBalance struct {
+	Asset  string `json:"a"`
+	Free   string `json:"f"`
+	Locked string `json:"l"`
+}
+
+func (e AccountPositionEvent) EventType() string { return e.Event }
+
+// Balance Update, sent on deposits, withdrawals and transfers
+// https://binance-docs.github.io/apidocs/spot/en/#balance-update
+type BalanceUpdateEvent struct {
+	Event     string `json:"e"`
+	Time      int64  `json:"E"`
+	Asset     string `json:"a"`
+	Delta     string `json:"d"`
+	ClearTime int64  `json:"T"`
+}
+
+func (e BalanceUpdateEvent) EventType() string { return e.Event }
+
+// Sent when the listenKey has expired. No more events are sent on the connection.
+type ListenKeyExpiredEvent struct {
+	Event     string `json:"e"`
+	Time      int64  `json:"E"`
+	ListenKey string `json:"listenKey"`
+}
+
+func (e ListenKeyExpiredEvent) EventType() string { return e.Event }
+
 // --------------------------------------------------------------------------------
 // System
 type ExchangeInfoResp struct {
@@ -70,45 +624,165 @@ type ExchangeInfoResp struct {
 }
 
 type ExchangeInfoRespX struct {
-	Timezone      string `json:"timezone"`
-	ServerTime    uint64 `json:"serverTime"`
-	ServerTimeStr string `json:"serverTimeStr"` //Not part of API
-	RateLimits    []struct {
-		RateLimitType string `json:"rateLimitType"`
-		Interval      string `json:"interval"`
-		Limit         int    `json:"limit"`
-	} `json:"rateLimits"`
+	Timezone        string        `json:"timezone"`
+	ServerTime      uint64        `json:"serverTime"`
+	ServerTimeStr   string        `json:"serverTimeStr"` //Not part of API
+	RateLimits      []RateLimit   `json:"rateLimits"`
 	ExchangeFilters []interface{} `json:"exchangeFilters"`
-	Symbols         []struct {
-		Symbol                     string   `json:"symbol"`
-		Status                     string   `json:"status"`
-		BaseAsset                  string   `json:"baseAsset"`
-		BaseAssetPrecision         int      `json:"baseAssetPrecision"`
-		QuoteAsset                 string   `json:"quoteAsset"`
-		QuotePrecision             int      `json:"quotePrecision"`
-		QuoteAssetPrecision        int      `json:"quoteAssetPrecision"`
-		BaseCommissionPrecision    int      `json:"baseCommissionPrecision"`
-		QuoteCommissionPrecision   int      `json:"quoteCommissionPrecision"`
-		OrderTypes                 []string `json:"orderTypes"`
-		IcebergAllowed             bool     `json:"icebergAllowed"`
-		OcoAllowed                 bool     `json:"ocoAllowed"`
-		QuoteOrderQtyMarketAllowed bool     `json:"quoteOrderQtyMarketAllowed"`
-		IsSpotTradingAllowed       bool     `json:"isSpotTradingAllowed"`
-		IsMarginTradingAllowed     bool     `json:"isMarginTradingAllowed"`
-		Filters                    []struct {
-			FilterType       string `json:"filterType"`
-			MinPrice         string `json:"minPrice,omitempty"`
-			MaxPrice         string `json:"maxPrice,omitempty"`
-			TickSize         string `json:"tickSize,omitempty"`
-			MinQty           string `json:"minQty,omitempty"`
-			MaxQty           string `json:"maxQty,omitempty"`
-			StepSize         string `json:"stepSize,omitempty"`
-			MinNotional      string `json:"minNotional,omitempty"`
-			Limit            int    `json:"limit,omitempty"`
-			MaxNumAlgoOrders int    `json:"maxNumAlgoOrders,omitempty"`
-		} `json:"filters"`
-		Permissions []string `json:"permissions"`
-	} `json:"symbols"`
+	Symbols         []SymbolInfo  `json:"symbols"`
+}
+
+// https://binance-docs.github.io/apidocs/spot/en/#limits
+// E.g. REQUEST_WEIGHT, MINUTE, 1, 6000 or ORDERS, SECOND, 10, 100
+type RateLimit struct {
+	RateLimitType string `json:"rateLimitType"`
+	Interval      string `json:"interval"`
+	IntervalNum   int    `json:"intervalNum"`
+	Limit         int    `json:"limit"`
+}
+
+// RateLimitUsage is the current usage of the rate limits, as reported by the X-MBX-USED-WEIGHT-* and X-MBX-ORDER-COUNT-* headers.
+type RateLimitUsage struct {
+	UsedWeight1m  int
+	WeightLimit1m int
+	OrderCount10s int
+	OrderLimit10s int
+	OrderCount1d  int
+	OrderLimit1d  int
+	RetryAfter    time.Time // Set after a 429 or 418 response
+	Banned        bool      // The IP is banned (418) until RetryAfter
+}
+
+type SymbolInfo struct {
+	Symbol                     string         `json:"symbol"`
+	Status                     string         `json:"status"`
+	BaseAsset                  string         `json:"baseAsset"`
+	BaseAssetPrecision         int            `json:"baseAssetPrecision"`
+	QuoteAsset                 string         `json:"quoteAsset"`
+	QuotePrecision             int            `json:"quotePrecision"`
+	QuoteAssetPrecision        int            `json:"quoteAssetPrecision"`
+	BaseCommissionPrecision    int            `json:"baseCommissionPrecision"`
+	QuoteCommissionPrecision   int            `json:"quoteCommissionPrecision"`
+	OrderTypes                 []string       `json:"orderTypes"`
+	IcebergAllowed             bool           `json:"icebergAllowed"`
+	OcoAllowed                 bool           `json:"ocoAllowed"`
+	QuoteOrderQtyMarketAllowed bool           `json:"quoteOrderQtyMarketAllowed"`
+	AllowTrailingStop          bool           `json:"allowTrailingStop"`
+	CancelReplaceAllowed       bool           `json:"cancelReplaceAllowed"`
+	IsSpotTradingAllowed       bool           `json:"isSpotTradingAllowed"`
+	IsMarginTradingAllowed     bool           `json:"isMarginTradingAllowed"`
+	Filters                    []SymbolFilter `json:"filters"`
+	Permissions                []string       `json:"permissions"`
+}
+
+// https://binance-docs.github.io/apidocs/spot/en/#filters
+type SymbolFilter struct {
+	FilterType       string `json:"filterType"`
+	MinPrice         string `json:"minPrice,omitempty"`
+	MaxPrice         string `json:"maxPrice,omitempty"`
+	TickSize         string `json:"tickSize,omitempty"`
+	MinQty           string `json:"minQty,omitempty"`
+	MaxQty           string `json:"maxQty,omitempty"`
+	StepSize         string `json:"stepSize,omitempty"`
+	MinNotional      string `json:"minNotional,omitempty"`
+	ApplyToMarket    bool   `json:"applyToMarket,omitempty"`    // MIN_NOTIONAL
+	ApplyMinToMarket bool   `json:"applyMinToMarket,omitempty"` // NOTIONAL
+	MaxNotional      string `json:"maxNotional,omitempty"`
+	ApplyMaxToMarket bool   `json:"applyMaxToMarket,omitempty"`
+	AvgPriceMins     int    `json:"avgPriceMins,omitempty"`
+	Limit            int    `json:"limit,omitempty"`
+	MaxNumAlgoOrders int    `json:"maxNumAlgoOrders,omitempty"`
+}
+
+// AllowsOrderType reports whether orders of the given type (MARKET, LIMIT, ...) can be placed on the symbol.
+func (s SymbolInfo) AllowsOrderType(orderType string) bool {
+	for _, t := range s.OrderTypes {
+		if t == orderType {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the filter with the given type, or nil if the symbol doesn't have it.
+func (s SymbolInfo) Filter(filterType string) *SymbolFilter {
+	for i := range s.Filters {
+		if s.Filters[i].FilterType == filterType {
+			return &s.Filters[i]
+		}
+	}
+	return nil
+}
+
+// EndpointStatus is the health of a REST API host, see client.EndpointPool.
+type EndpointStatus struct {
+	Host      string
+	Active    bool
+	Healthy   bool
+	Latency   time.Duration // Of the last health check
+	CheckedAt time.Time
+	Failures  int // Connection errors and 5xx responses
+	Switches  int // Times the host became active
+	LastError string
+}
+
+// --------------------------------------------------------------------------------
+// Account Information
+// https://binance-docs.github.io/apidocs/spot/en/#account-information-user_data
+type AccountResp struct {
+	MakerCommission  int `json:"makerCommission"`
+	TakerCommission  int `json:"takerCommission"`
+	BuyerCommission  int `json:"buyerCommission"`
+	SellerCommission int `json:"sellerCommission"`
+	CommissionRates  struct {
+		Maker  string `json:"maker"`
+		Taker  string `json:"taker"`
+		Buyer  string `json:"buyer"`
+		Seller string `json:"seller"`
+	} `json:"commissionRates"`
+	CanTrade                   bool      `json:"canTrade"`
+	CanWithdraw                bool      `json:"canWithdraw"`
+	CanDeposit                 bool      `json:"canDeposit"`
+	Brokered                   bool      `json:"brokered"`
+	RequireSelfTradePrevention bool      `json:"requireSelfTradePrevention"`
+	PreventSor                 bool      `json:"preventSor"`
+	UpdateTime                 uint64    `json:"updateTime"`
+	AccountType                string    `json:"accountType"`
+	Balances                   []Balance `json:"balances"`
+	Permissions                []string  `json:"permissions"`
+	Uid                        int64     `json:"uid"`
+}
+
+type Balance struct {
+	Asset  string `json:"asset"`
+	Free   string `json:"free"`
+	Locked string `json:"locked"`
+}
+
+// Balance returns the balance of an asset, e.g. BTC. Assets without a balance have zero free and locked.
+func (a AccountResp) Balance(asset string) Balance {
+	for _, b := range a.Balances {
+		if b.Asset == asset {
+			return b
+		}
+	}
+	return Balance{Asset: asset, Free: "0", Locked: "0"}
+}
+
+// Trade Fee
+// https://binance-docs.github.io/apidocs/spot/en/#trade-fee-user_data
+type TradeFeeResp struct {
+	Symbol          string `json:"symbol"`
+	MakerCommission string `json:"makerCommission"`
+	TakerCommission string `json:"takerCommission"`
+}
+
+// Query User Wallet Balance, the balance of each wallet in the quote asset (BTC by default)
+// https://binance-docs.github.io/apidocs/spot/en/#query-user-wallet-balance-user_data
+type WalletBalanceResp struct {
+	Activate   bool   `json:"activate"`
+	Balance    string `json:"balance"`
+	WalletName string `json:"walletName"`
 }
 
 // --------------------------------------------------------------------------------
@@ -0,0 +1,90 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+)
+
+// Exports of fills for reconciliation, e.g. of the trades returned by client.MyTrades.
+
+// Fill is one row of an export.
+type Fill struct {
+	Time            string `json:"time"` // UTC, RFC 3339 with milliseconds
+	Symbol          string `json:"symbol"`
+	TradeId         int64  `json:"tradeId"`
+	OrderId         int64  `json:"orderId"`
+	Side            string `json:"side"`
+	Price           string `json:"price"`
+	Qty             string `json:"qty"`
+	QuoteQty        string `json:"quoteQty"`
+	Commission      string `json:"commission"`
+	CommissionAsset string `json:"commissionAsset"`
+	IsMaker         bool   `json:"isMaker"`
+}
+
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+var csvHeader = []string{"time", "symbol", "tradeId", "orderId", "side", "price", "qty", "quoteQty", "commission", "commissionAsset", "isMaker"}
+
+func NewFill(t entity.Trade) Fill {
+	side := c.SIDE_SELL
+	if t.IsBuyer {
+		side = c.SIDE_BUY
+	}
+	return Fill{
+		Time:            time.UnixMilli(int64(t.Time)).UTC().Format(timeFormat),
+		Symbol:          t.Symbol,
+		TradeId:         t.Id,
+		OrderId:         t.OrderId,
+		Side:            side,
+		Price:           t.Price,
+		Qty:             t.Qty,
+		QuoteQty:        t.QuoteQty,
+		Commission:      t.Commission,
+		CommissionAsset: t.CommissionAsset,
+		IsMaker:         t.IsMaker,
+	}
+}
+
+// TradesCSV writes the trades as CSV with a header row.
+func TradesCSV(w io.Writer, trades []entity.Trade) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(csvHeader)
+	if err != nil {
+		return fmt.Errorf("error writing csv: %w", err)
+	}
+	for _, t := range trades {
+		f := NewFill(t)
+		err = cw.Write([]string{
+			f.Time, f.Symbol, strconv.FormatInt(f.TradeId, 10), strconv.FormatInt(f.OrderId, 10), f.Side,
+			f.Price, f.Qty, f.QuoteQty, f.Commission, f.CommissionAsset, strconv.FormatBool(f.IsMaker),
+		})
+		if err != nil {
+			return fmt.Errorf("error writing csv: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("error writing csv: %w", err)
+	}
+	return nil
+}
+
+// TradesJSONL writes the trades as JSON Lines, one fill per line.
+func TradesJSONL(w io.Writer, trades []entity.Trade) error {
+	enc := json.NewEncoder(w)
+	for _, t := range trades {
+		err := enc.Encode(NewFill(t))
+		if err != nil {
+			return fmt.Errorf("error writing json lines: %w", err)
+		}
+	}
+	return nil
+}
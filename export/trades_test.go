@@ -0,0 +1,74 @@
+package export
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/michelemendel/binance/entity"
+)
+
+// 2024-03-01T12:34:56.789Z
+const tradeTime = 1709296496789
+
+var trades = []entity.Trade{
+	{Symbol: "BTCFDUSD", Id: 1, OrderId: 10, Price: "60000.01", Qty: "0.001", QuoteQty: "60.00001", Commission: "0.00000100", CommissionAsset: "BTC", Time: tradeTime, IsBuyer: true},
+	{Symbol: "BTCFDUSD", Id: 2, OrderId: 11, Price: "61000", Qty: "0.001", QuoteQty: "61", Commission: "0.0001", CommissionAsset: "BNB", Time: tradeTime + 1, IsMaker: true},
+}
+
+func TestNewFill(t *testing.T) {
+	tests := []struct {
+		name  string
+		trade entity.Trade
+		want  Fill
+	}{
+		{"buyer", trades[0], Fill{
+			Time: "2024-03-01T12:34:56.789Z", Symbol: "BTCFDUSD", TradeId: 1, OrderId: 10, Side: "BUY",
+			Price: "60000.01", Qty: "0.001", QuoteQty: "60.00001", Commission: "0.00000100", CommissionAsset: "BTC",
+		}},
+		{"seller, maker", trades[1], Fill{
+			Time: "2024-03-01T12:34:56.790Z", Symbol: "BTCFDUSD", TradeId: 2, OrderId: 11, Side: "SELL",
+			Price: "61000", Qty: "0.001", QuoteQty: "61", Commission: "0.0001", CommissionAsset: "BNB", IsMaker: true,
+		}},
+		{"whole second keeps the milliseconds", entity.Trade{Time: 1709296496000}, Fill{Time: "2024-03-01T12:34:56.000Z", Side: "SELL"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewFill(tt.trade); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTradesExport(t *testing.T) {
+	tests := []struct {
+		name   string
+		export func(io.Writer, []entity.Trade) error
+		trades []entity.Trade
+		want   string
+	}{
+		{"csv", TradesCSV, trades,
+			"time,symbol,tradeId,orderId,side,price,qty,quoteQty,commission,commissionAsset,isMaker\n" +
+				"2024-03-01T12:34:56.789Z,BTCFDUSD,1,10,BUY,60000.01,0.001,60.00001,0.00000100,BTC,false\n" +
+				"2024-03-01T12:34:56.790Z,BTCFDUSD,2,11,SELL,61000,0.001,61,0.0001,BNB,true\n"},
+		{"csv without trades", TradesCSV, nil,
+			"time,symbol,tradeId,orderId,side,price,qty,quoteQty,commission,commissionAsset,isMaker\n"},
+		{"json lines", TradesJSONL, trades,
+			`{"time":"2024-03-01T12:34:56.789Z","symbol":"BTCFDUSD","tradeId":1,"orderId":10,"side":"BUY","price":"60000.01","qty":"0.001","quoteQty":"60.00001","commission":"0.00000100","commissionAsset":"BTC","isMaker":false}` + "\n" +
+				`{"time":"2024-03-01T12:34:56.790Z","symbol":"BTCFDUSD","tradeId":2,"orderId":11,"side":"SELL","price":"61000","qty":"0.001","quoteQty":"61","commission":"0.0001","commissionAsset":"BNB","isMaker":true}` + "\n"},
+		{"json lines without trades", TradesJSONL, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tt.export(&buf, tt.trades)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
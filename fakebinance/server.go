@@ -0,0 +1,711 @@
+// Package fakebinance is a fake Binance spot API for tests without network access.
+//
+// It serves ping, time, exchangeInfo, ticker/price, order (new, test, query and cancel), openOrders (query and
+// cancel), allOrders, account and myTrades on REST, and the combined market streams with live subscriptions on WebSocket. Market orders fill at once at
+// the symbol's price and move the balances, other orders stay open until filled with FillOrder. Responses can be
+// scripted per endpoint, e.g. to inject errors, and the requests are recorded.
+package fakebinance
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+)
+
+// Response is a scripted response.
+type Response struct {
+	Status   int // 200 if 0
+	Body     string
+	Header   http.Header
+	Executed bool // The request is handled as usual, but this response is sent instead, as if the response was lost
+}
+
+// Error returns a scripted Binance error, e.g. Error(400, ERROR_CODE_NEW_ORDER_REJECTED, "Account has insufficient balance").
+func Error(status, code int, msg string) Response {
+	body, _ := json.Marshal(map[string]any{"code": code, "msg": msg})
+	return Response{Status: status, Body: string(body)}
+}
+
+// Request is a request received on REST.
+type Request struct {
+	Method string
+	Path   string
+	Params url.Values // From both the query string and the body
+	Query  string
+	Body   string
+	APIKey string
+}
+
+// Server is a fake Binance server. Its URL is the base URL of the REST API, and WSURL the base URL of the streams.
+type Server struct {
+	*httptest.Server
+	APIKey    string // Required on all but NONE requests, if set
+	SecretKey string // Signed requests must be signed with it using HMAC, if set
+
+	mu       sync.Mutex
+	symbols  map[string]*symbol
+	balances map[string]float64
+	orders   []*entity.Order
+	trades   []entity.Trade
+	nextId   int64
+	scripts  map[string][]Response
+	requests []Request
+	streams  map[*websocket.Conn]*streamConn
+}
+
+type symbol struct {
+	info  entity.SymbolInfo
+	price float64
+}
+
+// NewServer starts a server with no symbols and no balances. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		symbols:  map[string]*symbol{},
+		balances: map[string]float64{},
+		scripts:  map[string][]Response{},
+		streams:  map[*websocket.Conn]*streamConn{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// WSURL returns the base URL of the streams, e.g. for Client.BaseWS.
+func (s *Server) WSURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+// AddSymbol adds a trading symbol at a price, with the usual filters: a tick size of 0.01,
+// a step size of 0.00001 and a minimum notional of 5.
+func (s *Server) AddSymbol(name, base, quote string, price float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.symbols[name] = &symbol{
+		price: price,
+		info: entity.SymbolInfo{
+			Symbol:                     name,
+			Status:                     c.SYMBOL_STATUS_TRADING,
+			BaseAsset:                  base,
+			BaseAssetPrecision:         8,
+			QuoteAsset:                 quote,
+			QuotePrecision:             8,
+			QuoteAssetPrecision:        8,
+			OrderTypes:                 []string{c.ORDER_TYPE_LIMIT, c.ORDER_TYPE_LIMIT_MAKER, c.ORDER_TYPE_MARKET, c.ORDER_TYPE_STOP_LOSS_LIMIT, c.ORDER_TYPE_TAKE_PROFIT_LIMIT},
+			IcebergAllowed:             true,
+			OcoAllowed:                 true,
+			QuoteOrderQtyMarketAllowed: true,
+			CancelReplaceAllowed:       true,
+			IsSpotTradingAllowed:       true,
+			Filters: []entity.SymbolFilter{
+				{FilterType: c.FILTER_PRICE, MinPrice: "0.01000000", MaxPrice: "1000000.00000000", TickSize: "0.01000000"},
+				{FilterType: c.FILTER_LOT_SIZE, MinQty: "0.00001000", MaxQty: "9000.00000000", StepSize: "0.00001000"},
+				{FilterType: c.FILTER_NOTIONAL, MinNotional: "5.00000000", MaxNotional: "9000000.00000000", ApplyMinToMarket: true, AvgPriceMins: 5},
+			},
+			Permissions: []string{"SPOT"},
+		},
+	}
+}
+
+// SetPrice sets the price of a symbol, and sends a mini ticker event with it to the streams subscribed to the symbol.
+func (s *Server) SetPrice(name string, price float64) {
+	s.mu.Lock()
+	sym, ok := s.symbols[name]
+	if ok {
+		sym.price = price
+	}
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	s.Push(strings.ToLower(name)+"@miniTicker", entity.MiniTickerEvent{
+		Event:  c.EVENT_MINI_TICKER,
+		Time:   time.Now().UnixMilli(),
+		Symbol: name,
+		Close:  formatFloat(price),
+	})
+}
+
+// SetBalance sets the free balance of an asset.
+func (s *Server) SetBalance(asset string, free float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.balances[asset] = free
+}
+
+// Balance returns the free balance of an asset.
+func (s *Server) Balance(asset string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.balances[asset]
+}
+
+// Script queues responses for an endpoint, e.g. Script("POST", PATH_ORDER, Error(503, ERROR_CODE_UNKNOWN, "")).
+// Each request to the endpoint gets the next one, until they're used up and the endpoint answers as usual.
+func (s *Server) Script(method, path string, responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := method + " " + path
+	s.scripts[key] = append(s.scripts[key], responses...)
+}
+
+// Requests returns the requests received for an endpoint, or all requests if method and path are empty.
+func (s *Server) Requests(method, path string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var requests []Request
+	for _, r := range s.requests {
+		if (method == "" || r.Method == method) && (path == "" || r.Path == path) {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/stream" {
+		s.serveStream(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	params, _ := url.ParseQuery(r.URL.RawQuery)
+	bodyParams, _ := url.ParseQuery(string(body))
+	for k, v := range bodyParams {
+		params[k] = v
+	}
+	req := Request{Method: r.Method, Path: r.URL.Path, Params: params, Query: r.URL.RawQuery, Body: string(body), APIKey: r.Header.Get("X-MBX-APIKEY")}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	key := r.Method + " " + r.URL.Path
+	scripted, isScripted := Response{}, len(s.scripts[key]) > 0
+	if isScripted {
+		scripted = s.scripts[key][0]
+		s.scripts[key] = s.scripts[key][1:]
+	}
+	s.mu.Unlock()
+
+	if isScripted && !scripted.Executed {
+		write(w, scripted)
+		return
+	}
+
+	securityType, ok := securityTypes[key]
+	if !ok {
+		write(w, Error(http.StatusNotFound, c.ERROR_CODE_UNKNOWN, "Unknown endpoint "+key))
+		return
+	}
+	if securityType != c.SECURITY_TYPE_NONE && s.APIKey != "" && req.APIKey != s.APIKey {
+		write(w, Error(http.StatusUnauthorized, c.ERROR_CODE_REJECTED_MBX_KEY, "Invalid API-key, IP, or permissions for action."))
+		return
+	}
+	if (securityType == c.SECURITY_TYPE_TRADE || securityType == c.SECURITY_TYPE_USER_DATA) && !s.verify(r.URL.RawQuery+string(body)) {
+		write(w, Error(http.StatusBadRequest, c.ERROR_CODE_INVALID_SIGNATURE, "Signature for this request is not valid."))
+		return
+	}
+
+	var resp any
+	var errResp *Response
+	switch key {
+	case "GET " + c.PATH_PING:
+		resp = map[string]any{}
+	case "GET " + c.PATH_TIME:
+		resp = entity.TimeResp{ServerTime: uint64(time.Now().UnixMilli())}
+	case "GET " + c.PATH_EXCHANGE_INFO:
+		resp, errResp = s.exchangeInfo(params)
+	case "GET " + c.PATH_TICKER_PRICE:
+		resp, errResp = s.tickerPrice(params)
+	case "POST " + c.PATH_ORDER:
+		resp, errResp = s.newOrder(params)
+	case "POST " + c.PATH_ORDER_TEST:
+		resp, errResp = s.testOrder(params)
+	case "GET " + c.PATH_ORDER:
+		resp, errResp = s.order(params)
+	case "DELETE " + c.PATH_ORDER:
+		resp, errResp = s.cancelOrder(params)
+	case "GET " + c.PATH_OPEN_ORDERS:
+		resp = s.openOrders(params)
+	case "DELETE " + c.PATH_OPEN_ORDERS:
+		resp, errResp = s.cancelOpenOrders(params)
+	case "GET " + c.PATH_ALL_ORDERS:
+		resp, errResp = s.allOrders(params)
+	case "GET " + c.PATH_ACCOUNT:
+		resp = s.account(params)
+	case "GET " + c.PATH_MY_TRADES:
+		resp = s.myTrades(params)
+	}
+	if isScripted {
+		write(w, scripted)
+		return
+	}
+	if errResp != nil {
+		write(w, *errResp)
+		return
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		write(w, Error(http.StatusInternalServerError, c.ERROR_CODE_UNKNOWN, err.Error()))
+		return
+	}
+	write(w, Response{Body: string(data)})
+}
+
+// Security types of the endpoints served
+var securityTypes = map[string]string{
+	"GET " + c.PATH_PING:           c.SECURITY_TYPE_NONE,
+	"GET " + c.PATH_TIME:           c.SECURITY_TYPE_NONE,
+	"GET " + c.PATH_EXCHANGE_INFO:  c.SECURITY_TYPE_NONE,
+	"GET " + c.PATH_TICKER_PRICE:   c.SECURITY_TYPE_NONE,
+	"POST " + c.PATH_ORDER:         c.SECURITY_TYPE_TRADE,
+	"POST " + c.PATH_ORDER_TEST:    c.SECURITY_TYPE_TRADE,
+	"GET " + c.PATH_ORDER:          c.SECURITY_TYPE_USER_DATA,
+	"DELETE " + c.PATH_ORDER:       c.SECURITY_TYPE_TRADE,
+	"GET " + c.PATH_OPEN_ORDERS:    c.SECURITY_TYPE_USER_DATA,
+	"DELETE " + c.PATH_OPEN_ORDERS: c.SECURITY_TYPE_TRADE,
+	"GET " + c.PATH_ALL_ORDERS:     c.SECURITY_TYPE_USER_DATA,
+	"GET " + c.PATH_ACCOUNT:        c.SECURITY_TYPE_USER_DATA,
+	"GET " + c.PATH_MY_TRADES:      c.SECURITY_TYPE_USER_DATA,
+}
+
+func write(w http.ResponseWriter, resp Response) {
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Content-Type", "application/json")
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, resp.Body)
+}
+
+// verify checks the HMAC signature of the query string and body, which is the last parameter.
+func (s *Server) verify(totalParams string) bool {
+	i := strings.LastIndex(totalParams, "signature=")
+	if i < 0 {
+		return false
+	}
+	if s.SecretKey == "" {
+		return true
+	}
+	payload := strings.TrimSuffix(totalParams[:i], "&")
+	mac := hmac.New(sha256.New, []byte(s.SecretKey))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil)) == totalParams[i+len("signature="):]
+}
+
+func invalidSymbol() *Response {
+	resp := Error(http.StatusBadRequest, c.ERROR_CODE_BAD_SYMBOL, "Invalid symbol.")
+	return &resp
+}
+
+func (s *Server) exchangeInfo(params url.Values) (any, *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := entity.ExchangeInfoRespX{
+		Timezone:   "UTC",
+		ServerTime: uint64(time.Now().UnixMilli()),
+		RateLimits: []entity.RateLimit{
+			{RateLimitType: c.RATE_LIMIT_REQUEST_WEIGHT, Interval: "MINUTE", IntervalNum: 1, Limit: c.DEFAULT_WEIGHT_LIMIT_1M},
+			{RateLimitType: c.RATE_LIMIT_ORDERS, Interval: "SECOND", IntervalNum: 10, Limit: c.DEFAULT_ORDER_LIMIT_10S},
+			{RateLimitType: c.RATE_LIMIT_ORDERS, Interval: "DAY", IntervalNum: 1, Limit: c.DEFAULT_ORDER_LIMIT_1D},
+		},
+		ExchangeFilters: []interface{}{},
+		Symbols:         []entity.SymbolInfo{},
+	}
+	if name := params.Get("symbol"); name != "" {
+		sym, ok := s.symbols[name]
+		if !ok {
+			return nil, invalidSymbol()
+		}
+		info.Symbols = append(info.Symbols, sym.info)
+		return info, nil
+	}
+	for _, sym := range s.symbols {
+		info.Symbols = append(info.Symbols, sym.info)
+	}
+	sort.Slice(info.Symbols, func(i, j int) bool { return info.Symbols[i].Symbol < info.Symbols[j].Symbol })
+	return info, nil
+}
+
+func (s *Server) tickerPrice(params url.Values) (any, *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sym, ok := s.symbols[params.Get("symbol")]
+	if !ok {
+		return nil, invalidSymbol()
+	}
+	return entity.PriceTickerResp{Symbol: sym.info.Symbol, Price: formatFloat(sym.price)}, nil
+}
+
+func (s *Server) testOrder(params url.Values) (any, *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.symbols[params.Get("symbol")]; !ok {
+		return nil, invalidSymbol()
+	}
+	var rates entity.TestOrderResp
+	rates.StandardCommissionForOrder.Maker = "0.00100000"
+	rates.StandardCommissionForOrder.Taker = "0.00100000"
+	rates.TaxCommissionForOrder.Maker = "0.00000000"
+	rates.TaxCommissionForOrder.Taker = "0.00000000"
+	rates.Discount.DiscountAsset = "BNB"
+	rates.Discount.Discount = "0.25000000"
+	return rates, nil
+}
+
+// newOrder fills market orders at the symbol's price without commission, and leaves other orders open.
+func (s *Server) newOrder(params url.Values) (any, *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sym, ok := s.symbols[params.Get("symbol")]
+	if !ok {
+		return nil, invalidSymbol()
+	}
+	clientOrderId := params.Get("newClientOrderId")
+	for _, o := range s.orders {
+		if clientOrderId != "" && o.ClientOrderId == clientOrderId && o.Status == "NEW" {
+			resp := Error(http.StatusBadRequest, c.ERROR_CODE_NEW_ORDER_REJECTED, "Duplicate order sent.")
+			return nil, &resp
+		}
+	}
+
+	now := uint64(time.Now().UnixMilli())
+	s.nextId++
+	order := &entity.Order{
+		Symbol:                  sym.info.Symbol,
+		OrderId:                 s.nextId,
+		OrderListId:             -1,
+		ClientOrderId:           clientOrderId,
+		Price:                   formatFloat(parseFloat(params.Get("price"))),
+		OrigQty:                 formatFloat(parseFloat(params.Get("quantity"))),
+		ExecutedQty:             "0",
+		CummulativeQuoteQty:     "0",
+		Status:                  "NEW",
+		TimeInForce:             params.Get("timeInForce"),
+		Type:                    params.Get("type"),
+		Side:                    params.Get("side"),
+		Time:                    now,
+		UpdateTime:              now,
+		IsWorking:               true,
+		WorkingTime:             now,
+		OrigQuoteOrderQty:       formatFloat(parseFloat(params.Get("quoteOrderQty"))),
+		SelfTradePreventionMode: "EXPIRE_MAKER",
+	}
+	resp := entity.CreateOrderResp{
+		Symbol:                  order.Symbol,
+		OrderId:                 order.OrderId,
+		OrderListId:             order.OrderListId,
+		ClientOrderId:           order.ClientOrderId,
+		TransactTime:            now,
+		Price:                   order.Price,
+		OrigQty:                 order.OrigQty,
+		TimeInForce:             order.TimeInForce,
+		Type:                    order.Type,
+		Side:                    order.Side,
+		WorkingTime:             now,
+		SelfTradePreventionMode: order.SelfTradePreventionMode,
+		Fills:                   []entity.Fill{},
+	}
+
+	if order.Type == c.ORDER_TYPE_MARKET {
+		qty := parseFloat(params.Get("quantity"))
+		if qty == 0 {
+			qty = parseFloat(params.Get("quoteOrderQty")) / sym.price
+		}
+		quoteQty := qty * sym.price
+		base, quote := sym.info.BaseAsset, sym.info.QuoteAsset
+		if (order.Side == c.SIDE_BUY && s.balances[quote] < quoteQty) || (order.Side == c.SIDE_SELL && s.balances[base] < qty) {
+			resp := Error(http.StatusBadRequest, c.ERROR_CODE_NEW_ORDER_REJECTED, "Account has insufficient balance for requested action.")
+			return nil, &resp
+		}
+		if order.Side == c.SIDE_BUY {
+			s.balances[quote] -= quoteQty
+			s.balances[base] += qty
+		} else {
+			s.balances[base] -= qty
+			s.balances[quote] += quoteQty
+		}
+		order.OrigQty = formatFloat(qty)
+		order.ExecutedQty = formatFloat(qty)
+		order.CummulativeQuoteQty = formatFloat(quoteQty)
+		order.Status = "FILLED"
+		order.IsWorking = false
+		resp.OrigQty = order.OrigQty
+		trade := s.trade(order, sym.price, qty, false)
+		resp.Fills = []entity.Fill{{Price: trade.Price, Qty: trade.Qty, Commission: trade.Commission, CommissionAsset: trade.CommissionAsset, TradeId: trade.Id}}
+	}
+	resp.ExecutedQty = order.ExecutedQty
+	resp.CummulativeQuoteQty = order.CummulativeQuoteQty
+	resp.Status = order.Status
+	s.orders = append(s.orders, order)
+	return resp, nil
+}
+
+// trade records a fill of an order without commission.
+func (s *Server) trade(order *entity.Order, price, qty float64, isMaker bool) entity.Trade {
+	sym := s.symbols[order.Symbol]
+	t := entity.Trade{
+		Symbol:          order.Symbol,
+		Id:              int64(len(s.trades) + 1),
+		OrderId:         order.OrderId,
+		OrderListId:     order.OrderListId,
+		Price:           formatFloat(price),
+		Qty:             formatFloat(qty),
+		QuoteQty:        formatFloat(price * qty),
+		Commission:      "0",
+		CommissionAsset: sym.info.QuoteAsset,
+		Time:            uint64(time.Now().UnixMilli()),
+		IsBuyer:         order.Side == c.SIDE_BUY,
+		IsMaker:         isMaker,
+		IsBestMatch:     true,
+	}
+	s.trades = append(s.trades, t)
+	return t
+}
+
+// AddTrade adds a trade to the trade list, e.g. one made before the test, and returns it with its id.
+// The balances aren't moved.
+func (s *Server) AddTrade(t entity.Trade) entity.Trade {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t.Id = int64(len(s.trades) + 1)
+	s.trades = append(s.trades, t)
+	return t
+}
+
+// FillOrder fills an open order at its price, as the maker, and moves the balances.
+// It returns false if there's no open order with the id.
+func (s *Server) FillOrder(symbol string, orderId int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, o := range s.orders {
+		if o.Symbol != symbol || o.OrderId != orderId || o.Status != "NEW" {
+			continue
+		}
+		price, qty := parseFloat(o.Price), parseFloat(o.OrigQty)
+		sym := s.symbols[symbol]
+		base, quote := sym.info.BaseAsset, sym.info.QuoteAsset
+		if o.Side == c.SIDE_BUY {
+			s.balances[quote] -= price * qty
+			s.balances[base] += qty
+		} else {
+			s.balances[base] -= qty
+			s.balances[quote] += price * qty
+		}
+		s.trade(o, price, qty, true)
+		o.ExecutedQty = o.OrigQty
+		o.CummulativeQuoteQty = formatFloat(price * qty)
+		o.Status = "FILLED"
+		o.IsWorking = false
+		o.UpdateTime = uint64(time.Now().UnixMilli())
+		return true
+	}
+	return false
+}
+
+// myTrades lists the trades of a symbol, of one order if orderId is given, and from a trade id if fromId is given.
+func (s *Server) myTrades(params url.Values) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orderId, _ := strconv.ParseInt(params.Get("orderId"), 10, 64)
+	fromId, _ := strconv.ParseInt(params.Get("fromId"), 10, 64)
+	limit, _ := strconv.Atoi(params.Get("limit"))
+	if limit == 0 {
+		limit = 500
+	}
+	trades := []entity.Trade{}
+	for _, t := range s.trades {
+		if t.Symbol == params.Get("symbol") && (orderId == 0 || t.OrderId == orderId) && t.Id >= fromId && len(trades) < limit {
+			trades = append(trades, t)
+		}
+	}
+	return trades
+}
+
+// find returns the order with the orderId or origClientOrderId of the parameters.
+func (s *Server) find(params url.Values) (*entity.Order, *Response) {
+	orderId, _ := strconv.ParseInt(params.Get("orderId"), 10, 64)
+	clientOrderId := params.Get("origClientOrderId")
+	for _, o := range s.orders {
+		if o.Symbol == params.Get("symbol") && ((orderId != 0 && o.OrderId == orderId) || (clientOrderId != "" && o.ClientOrderId == clientOrderId)) {
+			return o, nil
+		}
+	}
+	resp := Error(http.StatusBadRequest, c.ERROR_CODE_NO_SUCH_ORDER, "Order does not exist.")
+	return nil, &resp
+}
+
+func (s *Server) order(params url.Values) (any, *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, errResp := s.find(params)
+	if errResp != nil {
+		return nil, errResp
+	}
+	return *o, nil
+}
+
+func (s *Server) cancelOrder(params url.Values) (any, *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, errResp := s.find(params)
+	if errResp != nil {
+		resp := Error(http.StatusBadRequest, c.ERROR_CODE_CANCEL_REJECTED, "Unknown order sent.")
+		return nil, &resp
+	}
+	if o.Status != "NEW" {
+		resp := Error(http.StatusBadRequest, c.ERROR_CODE_CANCEL_REJECTED, "Unknown order sent.")
+		return nil, &resp
+	}
+	return cancel(o), nil
+}
+
+// AddOrder adds an order to the order list, e.g. one made before the test, and returns it with its id.
+// The balances aren't moved, and no trades are added.
+func (s *Server) AddOrder(o entity.Order) entity.Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextId++
+	o.OrderId = s.nextId
+	s.orders = append(s.orders, &o)
+	return o
+}
+
+// openOrders lists the open orders of a symbol, or of all symbols if none is given.
+func (s *Server) openOrders(params url.Values) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orders := []entity.Order{}
+	for _, o := range s.orders {
+		if (params.Get("symbol") == "" || o.Symbol == params.Get("symbol")) && o.Status == "NEW" {
+			orders = append(orders, *o)
+		}
+	}
+	return orders
+}
+
+func (s *Server) cancelOpenOrders(params url.Values) (any, *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.symbols[params.Get("symbol")]; !ok {
+		return nil, invalidSymbol()
+	}
+	canceled := []entity.CanceledOrder{}
+	for _, o := range s.orders {
+		if o.Symbol == params.Get("symbol") && o.Status == "NEW" {
+			canceled = append(canceled, cancel(o))
+		}
+	}
+	if len(canceled) == 0 {
+		resp := Error(http.StatusBadRequest, c.ERROR_CODE_CANCEL_REJECTED, "Unknown order sent.")
+		return nil, &resp
+	}
+	return canceled, nil
+}
+
+// allOrders lists the orders of a symbol from an order id if orderId is given, otherwise those created
+// between startTime and endTime, which may be at most 24 hours apart.
+func (s *Server) allOrders(params url.Values) (any, *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orderId, _ := strconv.ParseInt(params.Get("orderId"), 10, 64)
+	start, _ := strconv.ParseUint(params.Get("startTime"), 10, 64)
+	end, _ := strconv.ParseUint(params.Get("endTime"), 10, 64)
+	if end > 0 && end-start > uint64(c.MAX_QUERY_WINDOW.Milliseconds()) {
+		resp := Error(http.StatusBadRequest, c.ERROR_CODE_UNKNOWN, "More than 24 hours between startTime and endTime.")
+		return nil, &resp
+	}
+	limit, _ := strconv.Atoi(params.Get("limit"))
+	if limit == 0 {
+		limit = 500
+	}
+	orders := []entity.Order{}
+	for _, o := range s.orders {
+		if o.Symbol == params.Get("symbol") && o.OrderId >= orderId && o.Time >= start && (end == 0 || o.Time <= end) && len(orders) < limit {
+			orders = append(orders, *o)
+		}
+	}
+	return orders, nil
+}
+
+// cancel cancels an open order.
+func cancel(o *entity.Order) entity.CanceledOrder {
+	o.Status = "CANCELED"
+	o.IsWorking = false
+	o.UpdateTime = uint64(time.Now().UnixMilli())
+	return entity.CanceledOrder{
+		Symbol:                  o.Symbol,
+		OrigClientOrderId:       o.ClientOrderId,
+		OrderId:                 o.OrderId,
+		OrderListId:             o.OrderListId,
+		ClientOrderId:           o.ClientOrderId,
+		TransactTime:            o.UpdateTime,
+		Price:                   o.Price,
+		OrigQty:                 o.OrigQty,
+		ExecutedQty:             o.ExecutedQty,
+		CummulativeQuoteQty:     o.CummulativeQuoteQty,
+		Status:                  o.Status,
+		TimeInForce:             o.TimeInForce,
+		Type:                    o.Type,
+		Side:                    o.Side,
+		SelfTradePreventionMode: o.SelfTradePreventionMode,
+	}
+}
+
+func (s *Server) account(params url.Values) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account := entity.AccountResp{
+		MakerCommission: 10,
+		TakerCommission: 10,
+		CanTrade:        true,
+		CanWithdraw:     true,
+		CanDeposit:      true,
+		UpdateTime:      uint64(time.Now().UnixMilli()),
+		AccountType:     "SPOT",
+		Balances:        []entity.Balance{},
+		Permissions:     []string{"SPOT"},
+	}
+	account.CommissionRates.Maker = "0.00100000"
+	account.CommissionRates.Taker = "0.00100000"
+	account.CommissionRates.Buyer = "0.00000000"
+	account.CommissionRates.Seller = "0.00000000"
+	omitZero := params.Get("omitZeroBalances") == "true"
+	for asset, free := range s.balances {
+		if omitZero && free == 0 {
+			continue
+		}
+		account.Balances = append(account.Balances, entity.Balance{Asset: asset, Free: formatFloat(free), Locked: "0"})
+	}
+	sort.Slice(account.Balances, func(i, j int) bool { return account.Balances[i].Asset < account.Balances[j].Asset })
+	return account
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 8, 64)
+}
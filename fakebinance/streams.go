@@ -0,0 +1,125 @@
+package fakebinance
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	c "github.com/michelemendel/binance/constant"
+)
+
+// streamConn is a combined stream connection and the streams it's subscribed to.
+type streamConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	streams map[string]bool // Guarded by Server.mu
+}
+
+func (sc *streamConn) write(v any) error {
+	sc.writeMu.Lock()
+	defer sc.writeMu.Unlock()
+	return sc.conn.WriteJSON(v)
+}
+
+type streamRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	Id     int64    `json:"id"`
+}
+
+// serveStream serves a combined stream connection at /stream, answering SUBSCRIBE, UNSUBSCRIBE
+// and LIST_SUBSCRIPTIONS. Streams given in the URL, /stream?streams=a/b, are subscribed to from the start.
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	sc := &streamConn{conn: conn, streams: map[string]bool{}}
+	s.mu.Lock()
+	for _, stream := range strings.FieldsFunc(r.URL.Query().Get("streams"), func(r rune) bool { return r == '/' }) {
+		sc.streams[stream] = true
+	}
+	s.streams[conn] = sc
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	for {
+		var req streamRequest
+		err := conn.ReadJSON(&req)
+		if err != nil {
+			return
+		}
+		var result any
+		s.mu.Lock()
+		switch req.Method {
+		case c.WS_METHOD_SUBSCRIBE:
+			for _, p := range req.Params {
+				sc.streams[p] = true
+			}
+		case c.WS_METHOD_UNSUBSCRIBE:
+			for _, p := range req.Params {
+				delete(sc.streams, p)
+			}
+		case c.WS_METHOD_LIST_SUBSCRIPTIONS:
+			list := []string{}
+			for p := range sc.streams {
+				list = append(list, p)
+			}
+			sort.Strings(list)
+			result = list
+		}
+		s.mu.Unlock()
+		err = sc.write(map[string]any{"result": result, "id": req.Id})
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Push sends an event to the connections subscribed to the stream, e.g. "btcfdusd@miniTicker".
+// The event is marshalled to JSON and wrapped as {"stream":...,"data":...}.
+func (s *Server) Push(stream string, event any) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	msg := map[string]any{"stream": stream, "data": json.RawMessage(data)}
+	for _, sc := range s.subscribers(stream) {
+		sc.write(msg)
+	}
+}
+
+// Subscribers returns the number of connections subscribed to the stream.
+func (s *Server) Subscribers(stream string) int {
+	return len(s.subscribers(stream))
+}
+
+func (s *Server) subscribers(stream string) []*streamConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var conns []*streamConn
+	for _, sc := range s.streams {
+		if sc.streams[stream] {
+			conns = append(conns, sc)
+		}
+	}
+	return conns
+}
+
+// DropStreams closes all stream connections, as the server does at 24 hours.
+func (s *Server) DropStreams() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.streams {
+		conn.Close()
+	}
+}
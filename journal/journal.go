@@ -0,0 +1,331 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/michelemendel/binance/entity"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Journal is a local record of the orders placed and their fills, stored in a BoltDB file.
+// Orders are keyed by client order id, so recording an order again, e.g. after it's filled, replaces it.
+// Fills are keyed by symbol and trade id, so a fill can be recorded more than once, e.g. from both
+// the order response and the user data stream.
+// Dry-run orders are recorded, but left out of Orders unless IncludeDryRuns is set. They have no fills.
+type Journal struct {
+	IncludeDryRuns bool
+
+	db *bolt.DB
+}
+
+// Entry is an order as recorded in the journal.
+type Entry struct {
+	ClientOrderId string                 `json:"clientOrderId"`
+	Symbol        string                 `json:"symbol"`
+	Strategy      string                 `json:"strategy"`
+	Env           string                 `json:"env"`
+	DryRun        bool                   `json:"dryRun"`
+	RecordedAt    time.Time              `json:"recordedAt"`
+	Order         entity.CreateOrderResp `json:"order"`
+}
+
+// FillEntry is a fill as recorded in the journal, tagged with the order it belongs to if the order is in the journal.
+type FillEntry struct {
+	ClientOrderId string       `json:"clientOrderId"`
+	Strategy      string       `json:"strategy"`
+	Env           string       `json:"env"`
+	RecordedAt    time.Time    `json:"recordedAt"`
+	Trade         entity.Trade `json:"trade"`
+}
+
+var (
+	bucketOrders   = []byte("orders")      // clientOrderId -> Entry
+	bucketIndex    = []byte("symbol_time") // symbol, 0, transactTime, clientOrderId -> clientOrderId
+	bucketOrderIds = []byte("order_ids")   // symbol, 0, orderId, env -> clientOrderId
+	bucketFills    = []byte("fills")       // symbol, 0, tradeId, env -> FillEntry
+	bucketSync     = []byte("sync")        // env, 0, symbol -> id of the last trade fetched from the trade list
+)
+
+// Open opens the journal file, creating it if it doesn't exist.
+func Open(path string) (*Journal, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening journal %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{bucketOrders, bucketIndex, bucketOrderIds, bucketFills, bucketSync} {
+			_, err := tx.CreateBucketIfNotExists(b)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating journal buckets: %w", err)
+	}
+	return &Journal{db: db}, nil
+}
+
+func (j *Journal) Close() error {
+	return j.db.Close()
+}
+
+// Record stores an order and its fills, tagged with the strategy that placed it and the environment.
+func (j *Journal) Record(order entity.CreateOrderResp, strategy, env string) error {
+	if order.ClientOrderId == "" {
+		return fmt.Errorf("error recording order %d: no client order id", order.OrderId)
+	}
+	entry := Entry{
+		ClientOrderId: order.ClientOrderId,
+		Symbol:        order.Symbol,
+		Strategy:      strategy,
+		Env:           env,
+		DryRun:        order.DryRun,
+		RecordedAt:    time.Now(),
+		Order:         order,
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error encoding order %s: %w", order.ClientOrderId, err)
+	}
+
+	err = j.db.Update(func(tx *bolt.Tx) error {
+		orders := tx.Bucket(bucketOrders)
+		index := tx.Bucket(bucketIndex)
+		id := []byte(order.ClientOrderId)
+
+		// The transact time of a replaced entry may differ, e.g. for an order first found by a status query
+		if old := orders.Get(id); old != nil {
+			var prev Entry
+			if json.Unmarshal(old, &prev) == nil {
+				err := index.Delete(indexKey(prev.Symbol, prev.Order.TransactTime, prev.ClientOrderId))
+				if err != nil {
+					return err
+				}
+			}
+		}
+		err := orders.Put(id, data)
+		if err != nil {
+			return err
+		}
+		// Dry-run orders have no order id
+		if order.OrderId != 0 {
+			err = tx.Bucket(bucketOrderIds).Put(idKey(order.Symbol, order.OrderId, env), id)
+			if err != nil {
+				return err
+			}
+		}
+		return index.Put(indexKey(order.Symbol, order.TransactTime, order.ClientOrderId), id)
+	})
+	if err != nil {
+		return fmt.Errorf("error recording order %s: %w", order.ClientOrderId, err)
+	}
+	return nil
+}
+
+// RecordFill stores a fill, e.g. from an order response or an execution report, tagged with the environment.
+// If the order of the fill is in the journal, the fill gets its client order id and strategy.
+func (j *Journal) RecordFill(trade entity.Trade, env string) error {
+	err := j.db.Update(func(tx *bolt.Tx) error {
+		return putFill(tx, trade, env)
+	})
+	if err != nil {
+		return fmt.Errorf("error recording fill %d of %s: %w", trade.Id, trade.Symbol, err)
+	}
+	return nil
+}
+
+// RecordSync stores the fills of a symbol fetched from the trade list, which continue from SyncedTradeId,
+// and moves the synced trade id to the last of them.
+func (j *Journal) RecordSync(symbol string, trades []entity.Trade, env string) error {
+	err := j.db.Update(func(tx *bolt.Tx) error {
+		synced := syncedTradeId(tx, symbol, env)
+		for _, t := range trades {
+			err := putFill(tx, t, env)
+			if err != nil {
+				return err
+			}
+			if t.Id > synced {
+				synced = t.Id
+			}
+		}
+		return tx.Bucket(bucketSync).Put(syncKey(symbol, env), binary.BigEndian.AppendUint64(nil, uint64(synced)))
+	})
+	if err != nil {
+		return fmt.Errorf("error recording fills of %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// SyncedTradeId returns the id of the last trade of a symbol fetched from the trade list, or 0 if none has been.
+// Fills recorded otherwise don't count, since earlier fills may still be missing.
+func (j *Journal) SyncedTradeId(symbol, env string) (int64, error) {
+	var synced int64
+	err := j.db.View(func(tx *bolt.Tx) error {
+		synced = syncedTradeId(tx, symbol, env)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error querying journal: %w", err)
+	}
+	return synced, nil
+}
+
+func syncedTradeId(tx *bolt.Tx, symbol, env string) int64 {
+	v := tx.Bucket(bucketSync).Get(syncKey(symbol, env))
+	if len(v) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(v))
+}
+
+func putFill(tx *bolt.Tx, trade entity.Trade, env string) error {
+	entry := FillEntry{Env: env, RecordedAt: time.Now(), Trade: trade}
+	if id := tx.Bucket(bucketOrderIds).Get(idKey(trade.Symbol, trade.OrderId, env)); id != nil {
+		var order Entry
+		if json.Unmarshal(tx.Bucket(bucketOrders).Get(id), &order) == nil {
+			entry.ClientOrderId = order.ClientOrderId
+			entry.Strategy = order.Strategy
+		}
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketFills).Put(idKey(trade.Symbol, trade.Id, env), data)
+}
+
+// Fills returns the fills of a symbol made in [from, to), sorted by time.
+// All symbols are included if symbol is empty, and a zero to means no upper bound.
+func (j *Journal) Fills(symbol string, from, to time.Time) ([]FillEntry, error) {
+	var entries []FillEntry
+	err := j.db.View(func(tx *bolt.Tx) error {
+		var prefix []byte
+		if symbol != "" {
+			prefix = append([]byte(symbol), 0)
+		}
+		cur := tx.Bucket(bucketFills).Cursor()
+		for k, data := cur.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, data = cur.Next() {
+			var e FillEntry
+			err := json.Unmarshal(data, &e)
+			if err != nil {
+				return err
+			}
+			if inRange(e.Trade.Time, from, to) {
+				entries = append(entries, e)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error querying journal: %w", err)
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool { return entries[a].Trade.Time < entries[b].Trade.Time })
+	return entries, nil
+}
+
+// Order returns the entry of an order, or nil if it isn't in the journal.
+func (j *Journal) Order(clientOrderId string) (*Entry, error) {
+	var entry *Entry
+	err := j.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketOrders).Get([]byte(clientOrderId))
+		if data == nil {
+			return nil
+		}
+		entry = &Entry{}
+		return json.Unmarshal(data, entry)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading order %s: %w", clientOrderId, err)
+	}
+	return entry, nil
+}
+
+// Orders returns the orders of a symbol with a transact time in [from, to), sorted by time.
+// All symbols are included if symbol is empty, and a zero to means no upper bound.
+// Dry-run orders are only included if IncludeDryRuns is set.
+func (j *Journal) Orders(symbol string, from, to time.Time) ([]Entry, error) {
+	var entries []Entry
+	err := j.db.View(func(tx *bolt.Tx) error {
+		if symbol == "" {
+			return tx.Bucket(bucketOrders).ForEach(func(_, data []byte) error {
+				var e Entry
+				err := json.Unmarshal(data, &e)
+				if err != nil {
+					return err
+				}
+				if inRange(e.Order.TransactTime, from, to) && (!e.DryRun || j.IncludeDryRuns) {
+					entries = append(entries, e)
+				}
+				return nil
+			})
+		}
+
+		orders := tx.Bucket(bucketOrders)
+		prefix := append([]byte(symbol), 0)
+		cur := tx.Bucket(bucketIndex).Cursor()
+		start := uint64(0)
+		if from.UnixMilli() > 0 {
+			start = uint64(from.UnixMilli())
+		}
+		for k, id := cur.Seek(indexKey(symbol, start, "")); k != nil && bytes.HasPrefix(k, prefix); k, id = cur.Next() {
+			t := binary.BigEndian.Uint64(k[len(prefix):])
+			if !inRange(t, from, to) {
+				break
+			}
+			var e Entry
+			err := json.Unmarshal(orders.Get(id), &e)
+			if err != nil {
+				return err
+			}
+			if !e.DryRun || j.IncludeDryRuns {
+				entries = append(entries, e)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error querying journal: %w", err)
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool { return entries[a].Order.TransactTime < entries[b].Order.TransactTime })
+	return entries, nil
+}
+
+// indexKey orders the index by symbol and then transact time.
+func indexKey(symbol string, transactTime uint64, clientOrderId string) []byte {
+	key := make([]byte, 0, len(symbol)+1+8+len(clientOrderId))
+	key = append(key, symbol...)
+	key = append(key, 0)
+	key = binary.BigEndian.AppendUint64(key, transactTime)
+	return append(key, clientOrderId...)
+}
+
+// idKey orders order and trade ids by symbol and then id. The ids are only unique within an environment.
+func idKey(symbol string, id int64, env string) []byte {
+	key := make([]byte, 0, len(symbol)+1+8+len(env))
+	key = append(key, symbol...)
+	key = append(key, 0)
+	key = binary.BigEndian.AppendUint64(key, uint64(id))
+	return append(key, env...)
+}
+
+func syncKey(symbol, env string) []byte {
+	key := make([]byte, 0, len(env)+1+len(symbol))
+	key = append(key, env...)
+	key = append(key, 0)
+	return append(key, symbol...)
+}
+
+func inRange(millis uint64, from, to time.Time) bool {
+	t := int64(millis)
+	return t >= from.UnixMilli() && (to.IsZero() || t < to.UnixMilli())
+}
@@ -0,0 +1,186 @@
+package journal
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/michelemendel/binance/entity"
+)
+
+func TestJournal(t *testing.T) {
+	j, err := Open(filepath.Join(t.TempDir(), "journal.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	millis := func(h int) uint64 { return uint64(day.Add(time.Duration(h) * time.Hour).UnixMilli()) }
+	orders := []entity.CreateOrderResp{
+		{Symbol: "BTCFDUSD", ClientOrderId: "a", TransactTime: millis(1), Fills: []entity.Fill{{Price: "60000", Qty: "0.001"}}},
+		{Symbol: "BTCFDUSD", ClientOrderId: "b", TransactTime: millis(30)},
+		{Symbol: "ETHFDUSD", ClientOrderId: "c", TransactTime: millis(2)},
+		{Symbol: "BTCFDUSD", ClientOrderId: "d", TransactTime: millis(5)},
+		{Symbol: "BTCFDUSD", ClientOrderId: "e", TransactTime: millis(7), DryRun: true},
+	}
+	for _, o := range orders {
+		err := j.Record(o, "test", "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Recording an order again replaces it
+	orders[3].TransactTime = millis(6)
+	err = j.Record(orders[3], "test", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		symbol   string
+		from, to time.Time
+		dryRuns  bool
+		want     []string
+	}{
+		{"symbol, day", "BTCFDUSD", day, day.Add(24 * time.Hour), false, []string{"a", "d"}},
+		{"symbol, no end", "BTCFDUSD", day.Add(2 * time.Hour), time.Time{}, false, []string{"d", "b"}},
+		{"symbol, all", "BTCFDUSD", time.Time{}, time.Time{}, false, []string{"a", "d", "b"}},
+		{"all symbols", "", day, day.Add(24 * time.Hour), false, []string{"a", "c", "d"}},
+		{"unknown symbol", "XRPFDUSD", time.Time{}, time.Time{}, false, nil},
+		{"symbol, dry runs", "BTCFDUSD", day, day.Add(24 * time.Hour), true, []string{"a", "d", "e"}},
+		{"all symbols, dry runs", "", day, day.Add(24 * time.Hour), true, []string{"a", "c", "d", "e"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j.IncludeDryRuns = tt.dryRuns
+			entries, err := j.Orders(tt.symbol, tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.ClientOrderId)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	e, err := j.Order("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e == nil || e.Strategy != "test" || len(e.Order.Fills) != 1 {
+		t.Errorf("got %+v", e)
+	}
+}
+
+func TestJournalFills(t *testing.T) {
+	j, err := Open(filepath.Join(t.TempDir(), "journal.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	millis := func(h int) uint64 { return uint64(day.Add(time.Duration(h) * time.Hour).UnixMilli()) }
+	err = j.Record(entity.CreateOrderResp{Symbol: "BTCFDUSD", OrderId: 7, ClientOrderId: "a", TransactTime: millis(1)}, "grid", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trades := []entity.Trade{
+		{Symbol: "BTCFDUSD", Id: 10, OrderId: 7, Time: millis(1)},
+		{Symbol: "BTCFDUSD", Id: 12, OrderId: 7, Time: millis(3)}, // A later fill of the same order
+		{Symbol: "BTCFDUSD", Id: 11, OrderId: 8, Time: millis(2)}, // Of an order not in the journal
+		{Symbol: "BTC", Id: 99, OrderId: 1, Time: millis(1)},
+		{Symbol: "ETHFDUSD", Id: 5, OrderId: 3, Time: millis(30)},
+		{Symbol: "BTCFDUSD", Id: 10, OrderId: 7, Time: millis(1)}, // Recorded again, e.g. from the user data stream
+	}
+	for _, tr := range trades {
+		err := j.RecordFill(tr, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		symbol   string
+		from, to time.Time
+		want     []int64
+	}{
+		{"symbol, all", "BTCFDUSD", time.Time{}, time.Time{}, []int64{10, 11, 12}},
+		{"symbol, period", "BTCFDUSD", day.Add(2 * time.Hour), day.Add(3 * time.Hour), []int64{11}},
+		{"all symbols, day", "", day, day.Add(24 * time.Hour), []int64{99, 10, 11, 12}},
+		{"unknown symbol", "XRPFDUSD", time.Time{}, time.Time{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := j.Fills(tt.symbol, tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int64
+			for _, e := range entries {
+				got = append(got, e.Trade.Id)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	fills, err := j.Fills("BTCFDUSD", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := fills[2]; f.ClientOrderId != "a" || f.Strategy != "grid" || f.Env != "test" {
+		t.Errorf("fill of a journaled order: got %+v", f)
+	}
+	if f := fills[1]; f.ClientOrderId != "" || f.Strategy != "" {
+		t.Errorf("fill of another order: got %+v", f)
+	}
+
+	// Only fills from the trade list move the synced trade id
+	err = j.RecordSync("BTCFDUSD", []entity.Trade{{Symbol: "BTCFDUSD", Id: 14, Time: millis(4)}, {Symbol: "BTCFDUSD", Id: 13, Time: millis(4)}}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = j.RecordFill(entity.Trade{Symbol: "BTCFDUSD", Id: 20, Time: millis(5)}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Trade ids are only unique within an environment
+	err = j.RecordFill(entity.Trade{Symbol: "BTCFDUSD", Id: 14, Time: millis(6)}, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		symbol, env string
+		want        int64
+	}{{"BTCFDUSD", "test", 14}, {"BTCFDUSD", "prod", 0}, {"ETHFDUSD", "test", 0}} {
+		synced, err := j.SyncedTradeId(tt.symbol, tt.env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if synced != tt.want {
+			t.Errorf("synced trade id of %s in %s: got %d, want %d", tt.symbol, tt.env, synced, tt.want)
+		}
+	}
+	fills, err = j.Fills("BTCFDUSD", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 7 || fills[6].Env != "prod" || fills[4].Env != "test" {
+		t.Errorf("fills after sync: got %+v", fills)
+	}
+}
@@ -0,0 +1,182 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+	"github.com/michelemendel/binance/util"
+)
+
+// ErrOutOfSync is returned by Apply when a diff event doesn't follow the last update applied,
+// meaning events were missed and the book must be reloaded from a snapshot.
+var ErrOutOfSync = errors.New("order book out of sync")
+
+type Level struct {
+	Price float64
+	Qty   float64
+}
+
+// Book is a local order book, loaded from a REST snapshot and kept up to date with diff events,
+// following https://binance-docs.github.io/apidocs/spot/en/#how-to-manage-a-local-order-book-correctly
+type Book struct {
+	Symbol string
+
+	mu           sync.RWMutex
+	lastUpdateId int64
+	applied      bool    // Whether an event was applied since the snapshot
+	bids         []Level // Descending price
+	asks         []Level // Ascending price
+}
+
+func NewBook(symbol string) *Book {
+	return &Book{Symbol: symbol}
+}
+
+// Load replaces the book with a snapshot.
+func (b *Book) Load(snapshot entity.DepthResp) {
+	bids := make([]Level, 0, len(snapshot.Bids))
+	for _, l := range snapshot.Bids {
+		bids = append(bids, level(l))
+	}
+	asks := make([]Level, 0, len(snapshot.Asks))
+	for _, l := range snapshot.Asks {
+		asks = append(asks, level(l))
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bids = bids
+	b.asks = asks
+	b.lastUpdateId = snapshot.LastUpdateId
+	b.applied = false
+}
+
+// Apply applies a diff event. Events up to the last update id of the book are ignored.
+// The first event applied after the snapshot must contain lastUpdateId+1, and each following event
+// must start where the previous one ended, otherwise ErrOutOfSync is returned.
+func (b *Book) Apply(u entity.DepthUpdate) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if u.FinalUpdateId <= b.lastUpdateId {
+		return nil
+	}
+	if b.applied && u.FirstUpdateId != b.lastUpdateId+1 || !b.applied && u.FirstUpdateId > b.lastUpdateId+1 {
+		return fmt.Errorf("%w: %s expected update %d, got %d-%d", ErrOutOfSync, b.Symbol, b.lastUpdateId+1, u.FirstUpdateId, u.FinalUpdateId)
+	}
+
+	for _, l := range u.Bids {
+		b.bids = update(b.bids, level(l), func(a, b float64) bool { return a > b })
+	}
+	for _, l := range u.Asks {
+		b.asks = update(b.asks, level(l), func(a, b float64) bool { return a < b })
+	}
+	b.lastUpdateId = u.FinalUpdateId
+	b.applied = true
+	return nil
+}
+
+// LastUpdateId returns the update id the book is at.
+func (b *Book) LastUpdateId() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.lastUpdateId
+}
+
+func (b *Book) BestBid() (Level, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.bids) == 0 {
+		return Level{}, false
+	}
+	return b.bids[0], true
+}
+
+func (b *Book) BestAsk() (Level, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.asks) == 0 {
+		return Level{}, false
+	}
+	return b.asks[0], true
+}
+
+// Spread returns the best ask minus the best bid, and false if either side is empty.
+func (b *Book) Spread() (float64, bool) {
+	bid, okBid := b.BestBid()
+	ask, okAsk := b.BestAsk()
+	if !okBid || !okAsk {
+		return 0, false
+	}
+	return ask.Price - bid.Price, true
+}
+
+// MidPrice returns the price halfway between the best bid and ask, and false if either side is empty.
+func (b *Book) MidPrice() (float64, bool) {
+	bid, okBid := b.BestBid()
+	ask, okAsk := b.BestAsk()
+	if !okBid || !okAsk {
+		return 0, false
+	}
+	return (bid.Price + ask.Price) / 2, true
+}
+
+// Levels returns up to n of the best levels of a side, SIDE_BUY for bids and SIDE_SELL for asks.
+// All levels are returned if n is 0 or less.
+func (b *Book) Levels(side string, n int) []Level {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	levels := b.side(side)
+	if n <= 0 || n > len(levels) {
+		n = len(levels)
+	}
+	return append([]Level{}, levels[:n]...)
+}
+
+// Cumulative returns the total quantity and quote quantity of the levels of a side at the price or better,
+// e.g. what a market buy sweeping the asks up to the price would fill.
+func (b *Book) Cumulative(side string, price float64) (qty, quoteQty float64) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, l := range b.side(side) {
+		if side == c.SIDE_BUY && l.Price < price || side == c.SIDE_SELL && l.Price > price {
+			break
+		}
+		qty += l.Qty
+		quoteQty += l.Qty * l.Price
+	}
+	return qty, quoteQty
+}
+
+func (b *Book) side(side string) []Level {
+	if side == c.SIDE_BUY {
+		return b.bids
+	}
+	return b.asks
+}
+
+// update sets the quantity of a price level, removing it if the quantity is 0.
+// better tells whether a price sorts before another on this side.
+func update(levels []Level, l Level, better func(a, b float64) bool) []Level {
+	i := sort.Search(len(levels), func(i int) bool { return !better(levels[i].Price, l.Price) })
+	found := i < len(levels) && levels[i].Price == l.Price
+	switch {
+	case found && l.Qty == 0:
+		return append(levels[:i], levels[i+1:]...)
+	case found:
+		levels[i].Qty = l.Qty
+	case l.Qty != 0:
+		levels = append(levels, Level{})
+		copy(levels[i+1:], levels[i:])
+		levels[i] = l
+	}
+	return levels
+}
+
+func level(l [2]string) Level {
+	return Level{Price: util.String2Float(l[0]), Qty: util.String2Float(l[1])}
+}
@@ -0,0 +1,76 @@
+package orderbook
+
+import (
+	"errors"
+	"testing"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+)
+
+func TestBook(t *testing.T) {
+	b := NewBook("BTCFDUSD")
+	b.Load(entity.DepthResp{
+		LastUpdateId: 100,
+		Bids:         [][2]string{{"99", "1"}, {"98", "2"}, {"97", "3"}},
+		Asks:         [][2]string{{"101", "1"}, {"102", "2"}, {"103", "3"}},
+	})
+
+	tests := []struct {
+		name    string
+		update  entity.DepthUpdate
+		wantErr error
+	}{
+		{"older than snapshot is ignored", entity.DepthUpdate{FirstUpdateId: 90, FinalUpdateId: 100, Bids: [][2]string{{"99", "0"}}}, nil},
+		{"first straddles snapshot", entity.DepthUpdate{FirstUpdateId: 95, FinalUpdateId: 105,
+			Bids: [][2]string{{"99", "0"}, {"98.5", "4"}},
+			Asks: [][2]string{{"100", "5"}, {"102", "1"}},
+		}, nil},
+		{"next follows", entity.DepthUpdate{FirstUpdateId: 106, FinalUpdateId: 107, Asks: [][2]string{{"103", "0"}}}, nil},
+		{"gap", entity.DepthUpdate{FirstUpdateId: 109, FinalUpdateId: 110, Asks: [][2]string{{"100", "0"}}}, ErrOutOfSync},
+	}
+	for _, tt := range tests {
+		err := b.Apply(tt.update)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: got error %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if id := b.LastUpdateId(); id != 107 {
+		t.Errorf("lastUpdateId: got %d, want 107", id)
+	}
+	bid, _ := b.BestBid()
+	ask, _ := b.BestAsk()
+	if bid != (Level{98.5, 4}) || ask != (Level{100, 5}) {
+		t.Errorf("best bid/ask: got %v/%v", bid, ask)
+	}
+	if spread, _ := b.Spread(); spread != 1.5 {
+		t.Errorf("spread: got %v, want 1.5", spread)
+	}
+	asks := b.Levels(c.SIDE_SELL, 0)
+	if len(asks) != 3 || asks[1] != (Level{101, 1}) || asks[2] != (Level{102, 1}) {
+		t.Errorf("asks: got %v", asks)
+	}
+	if n := len(b.Levels(c.SIDE_SELL, -1)); n != 3 {
+		t.Errorf("asks with n -1: got %d levels, want 3", n)
+	}
+	if bids := b.Levels(c.SIDE_BUY, 1); len(bids) != 1 || bids[0] != (Level{98.5, 4}) {
+		t.Errorf("best bid level: got %v", bids)
+	}
+	qty, quoteQty := b.Cumulative(c.SIDE_BUY, 97.5)
+	if qty != 6 || quoteQty != 98.5*4+98*2 {
+		t.Errorf("cumulative bids: got %v, %v", qty, quoteQty)
+	}
+
+	// A snapshot after the gap restarts the sequence
+	b.Load(entity.DepthResp{LastUpdateId: 108})
+	err := b.Apply(entity.DepthUpdate{FirstUpdateId: 109, FinalUpdateId: 110, Asks: [][2]string{{"100", "1"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := entity.DepthUpdate{FirstUpdateId: 200, FinalUpdateId: 201}
+	b.Load(entity.DepthResp{LastUpdateId: 150})
+	if err := b.Apply(first); !errors.Is(err, ErrOutOfSync) {
+		t.Errorf("first event after the snapshot: got %v, want ErrOutOfSync", err)
+	}
+}
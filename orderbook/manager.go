@@ -0,0 +1,110 @@
+package orderbook
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/michelemendel/binance/client"
+	"github.com/michelemendel/binance/entity"
+	"github.com/michelemendel/binance/util"
+)
+
+// Manager maintains a local order book from a REST snapshot and the <symbol>@depth@100ms diff stream,
+// and reloads the snapshot whenever events are missed, e.g. when the stream reconnects.
+type Manager struct {
+	client *client.Client
+	limit  int
+	book   *Book
+}
+
+// NewManager returns a manager of the book of a symbol, with snapshots of limit levels per side.
+func NewManager(client *client.Client, symbol string, limit int) *Manager {
+	return &Manager{
+		client: client,
+		limit:  limit,
+		book:   NewBook(symbol),
+	}
+}
+
+// Book returns the book. It's empty until the first snapshot is loaded.
+func (m *Manager) Book() *Book {
+	return m.book
+}
+
+// Start subscribes to the diff stream and applies its events to the book until the context is cancelled.
+// As the documentation requires, the snapshot is loaded once the first event has arrived, and the events are
+// buffered by the stream meanwhile. A snapshot older than the first event is loaded again.
+// https://binance-docs.github.io/apidocs/spot/en/#how-to-manage-a-local-order-book-correctly
+func (m *Manager) Start(ctx context.Context) {
+	events := m.client.Stream(client.DepthStream(m.book.Symbol, true)).StartEvents(ctx)
+	go m.run(ctx, events)
+}
+
+func (m *Manager) run(ctx context.Context, events <-chan entity.MarketEvent) {
+	symbol := m.book.Symbol
+	// Backs off while snapshots keep failing or the book keeps falling out of sync right after one
+	for attempt := 1; ; attempt++ {
+		first, ok := nextUpdate(events)
+		if !ok {
+			slog.Info("depth stream closed", "symbol", symbol)
+			return
+		}
+		err := m.load(first)
+		if err == nil {
+			loadedAt := time.Now()
+			err = m.apply(first, events)
+			if err == nil {
+				return
+			}
+			if time.Since(loadedAt) > m.client.Retry.MaxDelay {
+				attempt = 1
+			}
+		}
+
+		delay := m.client.Retry.Backoff(attempt)
+		slog.Warn("resyncing order book", "symbol", symbol, "delay", delay, "error", err)
+		if !util.SleepCtx(ctx, delay) {
+			return
+		}
+	}
+}
+
+// load loads the snapshot, which must not be older than the first event buffered.
+func (m *Manager) load(first entity.DepthUpdate) error {
+	snapshot, err := m.client.Depth(m.book.Symbol, m.limit)
+	if err != nil {
+		return err
+	}
+	if snapshot.LastUpdateId < first.FirstUpdateId {
+		return fmt.Errorf("snapshot of %s at update %d is older than the first event at %d", m.book.Symbol, snapshot.LastUpdateId, first.FirstUpdateId)
+	}
+	m.book.Load(*snapshot)
+	slog.Info("loaded order book", "symbol", m.book.Symbol, "lastUpdateId", snapshot.LastUpdateId)
+	return nil
+}
+
+// apply applies the first event and the following ones until the stream is closed, which returns nil,
+// or until the book is out of sync.
+func (m *Manager) apply(first entity.DepthUpdate, events <-chan entity.MarketEvent) error {
+	u, ok := first, true
+	for ; ok; u, ok = nextUpdate(events) {
+		err := m.book.Apply(u)
+		if err != nil {
+			return err
+		}
+	}
+	slog.Info("depth stream closed", "symbol", m.book.Symbol)
+	return nil
+}
+
+// nextUpdate returns the next diff event, or false if the stream is closed.
+func nextUpdate(events <-chan entity.MarketEvent) (entity.DepthUpdate, bool) {
+	for e := range events {
+		if u, ok := e.(entity.DepthUpdate); ok {
+			return u, true
+		}
+	}
+	return entity.DepthUpdate{}, false
+}
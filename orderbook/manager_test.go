@@ -0,0 +1,69 @@
+package orderbook
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/michelemendel/binance/client"
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+	"github.com/michelemendel/binance/fakebinance"
+)
+
+func TestManager(t *testing.T) {
+	server := fakebinance.NewServer()
+	defer server.Close()
+	cl := client.NewClient("test", nil, "", "", server.URL, server.WSURL())
+	cl.Retry = client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	// The first snapshot is older than the first event, and is loaded again
+	server.Script(http.MethodGet, c.PATH_DEPTH,
+		fakebinance.Response{Body: `{"lastUpdateId":90,"bids":[["99","1"]],"asks":[["101","1"]]}`},
+		fakebinance.Response{Body: `{"lastUpdateId":102,"bids":[["99","1"],["98","2"]],"asks":[["101","1"],["102","2"]]}`},
+	)
+	snapshots := func() int { return len(server.Requests(http.MethodGet, c.PATH_DEPTH)) }
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(5 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := NewManager(cl, "BTCFDUSD", 100)
+	m.Start(ctx)
+	stream := client.DepthStream("BTCFDUSD", true)
+	waitFor("the subscription", func() bool { return server.Subscribers(stream) == 1 })
+
+	// No snapshot before the first event
+	time.Sleep(50 * time.Millisecond)
+	if n := snapshots(); n != 0 {
+		t.Fatalf("snapshots before the first event: got %d, want 0", n)
+	}
+
+	update := func(first, final int64, bids, asks [][2]string) entity.DepthUpdate {
+		return entity.DepthUpdate{Event: c.EVENT_DEPTH_UPDATE, Symbol: "BTCFDUSD", FirstUpdateId: first, FinalUpdateId: final, Bids: bids, Asks: asks}
+	}
+	server.Push(stream, update(95, 101, [][2]string{{"99", "3"}}, nil))
+	waitFor("the first snapshot", func() bool { return snapshots() == 1 })
+	time.Sleep(20 * time.Millisecond)
+	if id := m.Book().LastUpdateId(); id != 0 {
+		t.Fatalf("book loaded from a snapshot older than the first event, at %d", id)
+	}
+
+	// The next event is the first of the second snapshot
+	server.Push(stream, update(102, 103, [][2]string{{"98", "0"}}, nil))
+	server.Push(stream, update(104, 105, nil, [][2]string{{"100.5", "4"}}))
+	waitFor("the book at update 105", func() bool { return m.Book().LastUpdateId() == 105 })
+	if n := snapshots(); n != 2 {
+		t.Errorf("snapshots: got %d, want 2", n)
+	}
+	bids, asks := m.Book().Levels(c.SIDE_BUY, 0), m.Book().Levels(c.SIDE_SELL, 0)
+	if len(bids) != 1 || bids[0] != (Level{99, 1}) || len(asks) != 3 || asks[0] != (Level{100.5, 4}) {
+		t.Errorf("got bids %v and asks %v", bids, asks)
+	}
+}
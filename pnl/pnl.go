@@ -0,0 +1,317 @@
+package pnl
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	c "github.com/michelemendel/binance/constant"
+	"github.com/michelemendel/binance/entity"
+	"github.com/michelemendel/binance/util"
+)
+
+// Engine derives positions from fills and computes their realised and unrealised PnL in the quote asset.
+// The cost basis of a sale is taken from the lots bought first (FIFO), last (LIFO), or at their average cost.
+//
+// Commissions are converted to the quote asset: a commission in the base asset at the fill price,
+// and one in another asset, e.g. BNB, at the last price of that asset against the quote asset.
+// A commission in the base asset of a buy reduces the quantity received rather than adding to the cost.
+type Engine struct {
+	method string
+	assets func(symbol string) (base, quote string, err error)
+
+	mu        sync.Mutex
+	positions map[string]*position
+	prices    map[string]float64 // Last price by symbol
+}
+
+// Fill is a trade in one symbol, as taken from an order response or the trade list.
+type Fill struct {
+	Symbol          string
+	TradeId         int64 // A fill with a trade id is applied once, so it can be added from more than one source
+	Side            string
+	Price           float64
+	Qty             float64
+	Commission      float64
+	CommissionAsset string
+}
+
+// Position is a snapshot of the PnL of a symbol.
+type Position struct {
+	Symbol     string
+	Base       string
+	Quote      string
+	Qty        float64 // Held quantity of the base asset
+	Cost       float64 // Cost basis of the held quantity, commissions included
+	Realised   float64 // Proceeds less cost basis of what was sold, commissions included
+	Commission float64 // Total commission paid, in the quote asset
+	LastPrice  float64
+	Unrealised float64 // Market value of the held quantity less its cost basis
+	// Sold quantity with no lot to match, e.g. bought before the fills were recorded. It's excluded from the PnL.
+	Unmatched float64
+	// Commissions that couldn't be converted to the quote asset because no price was known, by asset
+	Unconverted map[string]float64
+}
+
+// AvgPrice returns the average cost per unit of the held quantity.
+func (p Position) AvgPrice() float64 {
+	if p.Qty == 0 {
+		return 0
+	}
+	return p.Cost / p.Qty
+}
+
+type lot struct {
+	qty  float64
+	cost float64 // Total, not per unit
+}
+
+type position struct {
+	Position
+	lots   []lot
+	trades map[int64]bool // Ids of the fills applied
+}
+
+// NewEngine returns an engine using a cost basis method, see COST_BASIS_*.
+// The assets function gives the base and quote asset of a symbol, e.g. SymbolRegistry.Assets.
+func NewEngine(method string, assets func(symbol string) (base, quote string, err error)) (*Engine, error) {
+	switch method {
+	case c.COST_BASIS_FIFO, c.COST_BASIS_LIFO, c.COST_BASIS_AVERAGE:
+	default:
+		return nil, fmt.Errorf("unknown cost basis method %s", method)
+	}
+	return &Engine{
+		method:    method,
+		assets:    assets,
+		positions: map[string]*position{},
+		prices:    map[string]float64{},
+	}, nil
+}
+
+// FillsFromOrder converts the fills of an order response.
+func FillsFromOrder(order entity.CreateOrderResp) []Fill {
+	fills := make([]Fill, len(order.Fills))
+	for i, f := range order.Fills {
+		fills[i] = Fill{
+			Symbol:          order.Symbol,
+			TradeId:         f.TradeId,
+			Side:            order.Side,
+			Price:           util.String2Float(f.Price),
+			Qty:             util.String2Float(f.Qty),
+			Commission:      util.String2Float(f.Commission),
+			CommissionAsset: f.CommissionAsset,
+		}
+	}
+	return fills
+}
+
+// FillFromTrade converts a trade from the trade list.
+func FillFromTrade(t entity.Trade) Fill {
+	side := c.SIDE_SELL
+	if t.IsBuyer {
+		side = c.SIDE_BUY
+	}
+	return Fill{
+		Symbol:          t.Symbol,
+		TradeId:         t.Id,
+		Side:            side,
+		Price:           util.String2Float(t.Price),
+		Qty:             util.String2Float(t.Qty),
+		Commission:      util.String2Float(t.Commission),
+		CommissionAsset: t.CommissionAsset,
+	}
+}
+
+// AddOrder adds the fills of an order.
+func (e *Engine) AddOrder(order entity.CreateOrderResp) error {
+	for _, f := range FillsFromOrder(order) {
+		_, err := e.Add(f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add applies a fill to its position, in the order the fills were made, and returns the updated position.
+// A fill with a trade id that's already been applied is skipped.
+func (e *Engine) Add(f Fill) (Position, error) {
+	p, err := e.position(f.Symbol)
+	if err != nil {
+		return Position{}, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if f.TradeId != 0 && p.trades[f.TradeId] {
+		return e.snapshot(p), nil
+	}
+	if f.Side != c.SIDE_BUY && f.Side != c.SIDE_SELL {
+		return Position{}, fmt.Errorf("unknown side %s of fill in %s", f.Side, f.Symbol)
+	}
+	if f.TradeId != 0 {
+		p.trades[f.TradeId] = true
+	}
+
+	qty := f.Qty
+	fee := 0.0
+	switch f.CommissionAsset {
+	case "":
+	case p.Base:
+		// Paid out of the base asset bought, or on top of the base asset sold
+		if f.Side == c.SIDE_BUY {
+			qty -= f.Commission
+		} else {
+			qty += f.Commission
+		}
+		p.Commission += f.Commission * f.Price
+	default:
+		fee = e.toQuote(p, f.Commission, f.CommissionAsset)
+		p.Commission += fee
+	}
+
+	switch f.Side {
+	case c.SIDE_BUY:
+		e.buy(p, lot{qty: qty, cost: f.Price*f.Qty + fee})
+	case c.SIDE_SELL:
+		cost, matched := e.sell(p, qty)
+		p.Unmatched += qty - matched
+		proceeds := f.Price*f.Qty - fee
+		if qty > 0 {
+			// Only the matched part of the sale is realised
+			proceeds *= matched / qty
+		}
+		p.Realised += proceeds - cost
+	}
+
+	if _, ok := e.prices[f.Symbol]; !ok {
+		e.prices[f.Symbol] = f.Price
+	}
+	return e.snapshot(p), nil
+}
+
+// Price records the last price of a symbol, e.g. from a ticker event, and returns the position
+// with its unrealised PnL at that price. The boolean is false if there's no position in the symbol.
+func (e *Engine) Price(symbol string, price float64) (Position, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.prices[symbol] = price
+	p, ok := e.positions[symbol]
+	if !ok {
+		return Position{}, false
+	}
+	return e.snapshot(p), true
+}
+
+// Position returns the position in a symbol.
+func (e *Engine) Position(symbol string) (Position, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	p, ok := e.positions[symbol]
+	if !ok {
+		return Position{}, false
+	}
+	return e.snapshot(p), true
+}
+
+// Positions returns all positions, sorted by symbol.
+func (e *Engine) Positions() []Position {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	positions := make([]Position, 0, len(e.positions))
+	for _, p := range e.positions {
+		positions = append(positions, e.snapshot(p))
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Symbol < positions[j].Symbol })
+	return positions
+}
+
+// position returns the position in a symbol, and creates it if there's none.
+// The assets of a new position are looked up without holding the lock, as that may be a network call.
+func (e *Engine) position(symbol string) (*position, error) {
+	e.mu.Lock()
+	p, ok := e.positions[symbol]
+	e.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+
+	base, quote, err := e.assets(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("error getting assets of %s: %w", symbol, err)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if p, ok := e.positions[symbol]; ok {
+		return p, nil
+	}
+	p = &position{Position: Position{Symbol: symbol, Base: base, Quote: quote, Unconverted: map[string]float64{}}, trades: map[int64]bool{}}
+	e.positions[symbol] = p
+	return p, nil
+}
+
+func (e *Engine) buy(p *position, l lot) {
+	if e.method == c.COST_BASIS_AVERAGE && len(p.lots) > 0 {
+		p.lots[0].qty += l.qty
+		p.lots[0].cost += l.cost
+		return
+	}
+	p.lots = append(p.lots, l)
+}
+
+// sell removes up to qty from the lots, and returns the cost basis and quantity removed.
+func (e *Engine) sell(p *position, qty float64) (cost, matched float64) {
+	for qty > 0 && len(p.lots) > 0 {
+		i := 0
+		if e.method == c.COST_BASIS_LIFO {
+			i = len(p.lots) - 1
+		}
+		l := &p.lots[i]
+		take := qty
+		if l.qty <= take {
+			take = l.qty
+		}
+		part := l.cost * take / l.qty
+		cost += part
+		matched += take
+		qty -= take
+		l.qty -= take
+		l.cost -= part
+		// Rounding may leave dust
+		if l.qty <= 1e-12 {
+			p.lots = append(p.lots[:i], p.lots[i+1:]...)
+		}
+	}
+	return cost, matched
+}
+
+// toQuote converts an amount of an asset to the quote asset of the position, using the last price of asset+quote.
+func (e *Engine) toQuote(p *position, amount float64, asset string) float64 {
+	if asset == p.Quote {
+		return amount
+	}
+	if price, ok := e.prices[asset+p.Quote]; ok {
+		return amount * price
+	}
+	slog.Warn("no price to convert commission", "asset", asset, "quote", p.Quote, "amount", amount)
+	p.Unconverted[asset] += amount
+	return 0
+}
+
+func (e *Engine) snapshot(p *position) Position {
+	s := p.Position
+	s.Qty, s.Cost = 0, 0
+	for _, l := range p.lots {
+		s.Qty += l.qty
+		s.Cost += l.cost
+	}
+	s.LastPrice = e.prices[p.Symbol]
+	s.Unrealised = s.Qty*s.LastPrice - s.Cost
+	s.Unconverted = make(map[string]float64, len(p.Unconverted))
+	for asset, amount := range p.Unconverted {
+		s.Unconverted[asset] = amount
+	}
+	return s
+}
@@ -0,0 +1,144 @@
+package pnl
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	c "github.com/michelemendel/binance/constant"
+)
+
+func assets(symbol string) (string, string, error) {
+	return symbol[:3], symbol[3:], nil
+}
+
+func TestEngine(t *testing.T) {
+	// Bought 1 at 100 and 1 at 200, sold 1 at 300 with a commission of 1 FDUSD
+	fills := []Fill{
+		{Symbol: "BTCFDUSD", Side: c.SIDE_BUY, Price: 100, Qty: 1},
+		{Symbol: "BTCFDUSD", Side: c.SIDE_BUY, Price: 200, Qty: 1},
+		{Symbol: "BTCFDUSD", Side: c.SIDE_SELL, Price: 300, Qty: 1, Commission: 1, CommissionAsset: "FDUSD"},
+	}
+
+	tests := []struct {
+		method     string
+		realised   float64
+		cost       float64
+		unrealised float64 // At 250
+	}{
+		{c.COST_BASIS_FIFO, 199, 200, 50},
+		{c.COST_BASIS_LIFO, 99, 100, 150},
+		{c.COST_BASIS_AVERAGE, 149, 150, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			e, err := NewEngine(tt.method, assets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range fills {
+				_, err := e.Add(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			p, ok := e.Price("BTCFDUSD", 250)
+			if !ok {
+				t.Fatal("no position")
+			}
+			check(t, "qty", p.Qty, 1)
+			check(t, "realised", p.Realised, tt.realised)
+			check(t, "cost", p.Cost, tt.cost)
+			check(t, "unrealised", p.Unrealised, tt.unrealised)
+			check(t, "commission", p.Commission, 1)
+		})
+	}
+}
+
+func TestEngineCommissions(t *testing.T) {
+	e, _ := NewEngine(c.COST_BASIS_FIFO, assets)
+	e.Price("BNBFDUSD", 500)
+
+	// A commission in the base asset reduces the quantity received
+	p, _ := e.Add(Fill{Symbol: "BTCFDUSD", Side: c.SIDE_BUY, Price: 100, Qty: 1, Commission: 0.01, CommissionAsset: "BTC"})
+	check(t, "qty", p.Qty, 0.99)
+	check(t, "cost", p.Cost, 100)
+	check(t, "commission", p.Commission, 1)
+
+	// A commission in BNB is converted at the BNB price
+	p, _ = e.Add(Fill{Symbol: "BTCFDUSD", Side: c.SIDE_SELL, Price: 200, Qty: 0.99, Commission: 0.002, CommissionAsset: "BNB"})
+	check(t, "qty", p.Qty, 0)
+	check(t, "realised", p.Realised, 200*0.99-1-100)
+	check(t, "commission", p.Commission, 2)
+
+	// Without a price the commission is kept aside, and a sale without lots isn't realised
+	p, _ = e.Add(Fill{Symbol: "BTCFDUSD", Side: c.SIDE_SELL, Price: 200, Qty: 0.5, Commission: 1, CommissionAsset: "XRP"})
+	check(t, "unmatched", p.Unmatched, 0.5)
+	check(t, "realised", p.Realised, 200*0.99-1-100)
+	check(t, "unconverted", p.Unconverted["XRP"], 1)
+}
+
+func TestEngineTradeIds(t *testing.T) {
+	e, _ := NewEngine(c.COST_BASIS_FIFO, assets)
+
+	// The same fill from the order response and the trade list is applied once
+	fill := Fill{Symbol: "BTCFDUSD", TradeId: 7, Side: c.SIDE_BUY, Price: 100, Qty: 1}
+	e.Add(fill)
+	p, _ := e.Add(fill)
+	check(t, "qty", p.Qty, 1)
+
+	// Fills without a trade id are always applied
+	fill.TradeId = 0
+	e.Add(fill)
+	p, _ = e.Add(fill)
+	check(t, "qty", p.Qty, 3)
+
+	// The trade ids are per symbol
+	p, _ = e.Add(Fill{Symbol: "ETHFDUSD", TradeId: 7, Side: c.SIDE_BUY, Price: 10, Qty: 1})
+	check(t, "qty", p.Qty, 1)
+
+	_, err := e.Add(Fill{Symbol: "BTCFDUSD", TradeId: 8, Side: "HOLD", Qty: 1})
+	if err == nil {
+		t.Error("unknown side: got no error")
+	}
+}
+
+func TestEngineAssetsLookup(t *testing.T) {
+	looking, release := make(chan struct{}), make(chan struct{})
+	e, _ := NewEngine(c.COST_BASIS_FIFO, func(symbol string) (string, string, error) {
+		if symbol == "BTCFDUSD" {
+			close(looking)
+			<-release
+		}
+		return assets(symbol)
+	})
+	e.Add(Fill{Symbol: "ETHFDUSD", TradeId: 1, Side: c.SIDE_BUY, Price: 10, Qty: 1})
+
+	// A slow assets lookup of a new position doesn't block the other positions
+	added := make(chan Position)
+	go func() {
+		p, _ := e.Add(Fill{Symbol: "BTCFDUSD", TradeId: 1, Side: c.SIDE_BUY, Price: 100, Qty: 1})
+		added <- p
+	}()
+	<-looking
+	done := make(chan struct{})
+	go func() {
+		e.Price("ETHFDUSD", 12)
+		e.Position("ETHFDUSD")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Price and Position blocked by the assets lookup")
+	}
+	close(release)
+	check(t, "qty", (<-added).Qty, 1)
+}
+
+func check(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s: got %v, want %v", name, got, want)
+	}
+}
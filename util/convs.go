@@ -13,3 +13,8 @@ func String2Float(s string) float64 {
 	}
 	return f
 }
+
+// Float2String formats without exponent and with the minimal number of decimals, e.g. 0.00136
+func Float2String(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,17 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+)
+
+// NewClientOrderId returns a unique id matching Binance's ^[\.A-Z\:/a-z0-9_-]{1,36}$
+func NewClientOrderId() string {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "x-" + strconv.FormatInt(TimeNowInMillis(), 10)
+	}
+	return "x-" + hex.EncodeToString(b)
+}
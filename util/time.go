@@ -1,6 +1,9 @@
 package util
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 func TimeNowAsString() string {
 	return time.Now().Format(time.RFC3339Nano)
@@ -13,3 +16,13 @@ func Time2String(t uint64) string {
 func TimeNowInMillis() int64 {
 	return time.Now().UnixNano() / 1e6
 }
+
+// SleepCtx waits for the delay, and returns false if the context is cancelled first.
+func SleepCtx(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}